@@ -1,6 +1,7 @@
 package gobcy
 
 import (
+	"context"
 	"errors"
 	"strconv"
 )
@@ -8,12 +9,19 @@ import (
 //GetAddrBal returns balance information for a given public
 //address. Fastest Address API call, but does not
 //include transaction details.
+//See AddrQuery for its supported params.
 func (api *API) GetAddrBal(hash string, params map[string]string) (addr Addr, err error) {
+	return api.GetAddrBalCtx(context.Background(), hash, params)
+}
+
+//GetAddrBalCtx is like GetAddrBal, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetAddrBalCtx(ctx context.Context, hash string, params map[string]string) (addr Addr, err error) {
 	u, err := api.buildURL("/addrs/"+hash+"/balance", params)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &addr)
+	err = api.getResponse(ctx, u, &addr)
 	return
 }
 
@@ -22,25 +30,39 @@ func (api *API) GetAddrBal(hash string, params map[string]string) (addr Addr, er
 //transaction outpus via the TXRef arrays in the Address
 //type. Returns more information than GetAddrBal, but
 //slightly slower.
+//See AddrQuery for its supported params.
 func (api *API) GetAddr(hash string, params map[string]string) (addr Addr, err error) {
+	return api.GetAddrCtx(context.Background(), hash, params)
+}
+
+//GetAddrCtx is like GetAddr, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetAddrCtx(ctx context.Context, hash string, params map[string]string) (addr Addr, err error) {
 	u, err := api.buildURL("/addrs/"+hash, params)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &addr)
+	err = api.getResponse(ctx, u, &addr)
 	return
 }
 
 //GetAddrNext returns a given Addr's next page of TXRefs,
 //if Addr.HasMore is true. If HasMore is false, will
 //return an error. It assumes default API URL parameters.
+//Use IterAddr to walk every TXRef instead.
 func (api *API) GetAddrNext(this Addr) (next Addr, err error) {
+	return api.GetAddrNextCtx(context.Background(), this)
+}
+
+//GetAddrNextCtx is like GetAddrNext, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetAddrNextCtx(ctx context.Context, this Addr) (next Addr, err error) {
 	if !this.HasMore {
 		err = errors.New("Func GetAddrNext: this Addr doesn't have more TXRefs according to its HasMore")
 		return
 	}
 	before := this.TXRefs[len(this.TXRefs)-1].BlockHeight
-	next, err = api.GetAddr(this.Address, map[string]string{"before": strconv.Itoa(before)})
+	next, err = api.GetAddrCtx(ctx, this.Address, map[string]string{"before": strconv.Itoa(before)})
 	return
 }
 
@@ -48,25 +70,39 @@ func (api *API) GetAddrNext(this Addr) (next Addr, err error) {
 //address, including a slice of TXs associated
 //with this address. Returns more data than GetAddr since
 //it includes full transactions, but slowest Address query.
+//See AddrQuery for its supported params.
 func (api *API) GetAddrFull(hash string, params map[string]string) (addr Addr, err error) {
+	return api.GetAddrFullCtx(context.Background(), hash, params)
+}
+
+//GetAddrFullCtx is like GetAddrFull, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetAddrFullCtx(ctx context.Context, hash string, params map[string]string) (addr Addr, err error) {
 	u, err := api.buildURL("/addrs/"+hash+"/full", params)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &addr)
+	err = api.getResponse(ctx, u, &addr)
 	return
 }
 
 //GetAddrFullNext returns a given Addr's next page of TXs,
 //if Addr.HasMore is true. If HasMore is false, will
 //return an error. It assumes default API URL parameters, like GetAddrFull.
+//Use IterAddrFull to walk every TX instead.
 func (api *API) GetAddrFullNext(this Addr) (next Addr, err error) {
+	return api.GetAddrFullNextCtx(context.Background(), this)
+}
+
+//GetAddrFullNextCtx is like GetAddrFullNext, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetAddrFullNextCtx(ctx context.Context, this Addr) (next Addr, err error) {
 	if !this.HasMore {
 		err = errors.New("Func GetAddrFullNext: this Addr doesn't have more TXs according to its HasMore")
 		return
 	}
 	before := this.TXs[len(this.TXs)-1].BlockHeight
-	next, err = api.GetAddrFull(this.Address, map[string]string{"before": strconv.Itoa(before)})
+	next, err = api.GetAddrFullCtx(ctx, this.Address, map[string]string{"before": strconv.Itoa(before)})
 	return
 }
 
@@ -75,11 +111,17 @@ func (api *API) GetAddrFullNext(this Addr) (next Addr, err error) {
 //this call must be made over SSL, and it is not recommended to keep
 //large amounts in these addresses, or for very long.
 func (api *API) GenAddrKeychain() (pair AddrKeychain, err error) {
+	return api.GenAddrKeychainCtx(context.Background())
+}
+
+//GenAddrKeychainCtx is like GenAddrKeychain, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GenAddrKeychainCtx(ctx context.Context) (pair AddrKeychain, err error) {
 	u, err := api.buildURL("/addrs", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, nil, &pair)
+	err = api.postResponse(ctx, u, nil, &pair)
 	return
 }
 
@@ -89,6 +131,12 @@ func (api *API) GenAddrKeychain() (pair AddrKeychain, err error) {
 //an AddrKeychain with the same PubKeys, ScriptType, and the proper
 //P2SH address in the AddrKeychain's address field.
 func (api *API) GenAddrMultisig(multi AddrKeychain) (addr AddrKeychain, err error) {
+	return api.GenAddrMultisigCtx(context.Background(), multi)
+}
+
+//GenAddrMultisigCtx is like GenAddrMultisig, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GenAddrMultisigCtx(ctx context.Context, multi AddrKeychain) (addr AddrKeychain, err error) {
 	if len(multi.PubKeys) == 0 || multi.ScriptType == "" {
 		err = errors.New("GenAddrMultisig: PubKeys or ScriptType are empty.")
 		return
@@ -97,7 +145,7 @@ func (api *API) GenAddrMultisig(multi AddrKeychain) (addr AddrKeychain, err erro
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &multi, &addr)
+	err = api.postResponse(ctx, u, &multi, &addr)
 	return
 }
 
@@ -105,6 +153,12 @@ func (api *API) GenAddrMultisig(multi AddrKeychain) (addr AddrKeychain, err erro
 //Testnet and Bitcoin Testnet3. Returns the transaction hash funding
 //your AddrKeychain.
 func (api *API) Faucet(a AddrKeychain, amount int) (txhash string, err error) {
+	return api.FaucetCtx(context.Background(), a, amount)
+}
+
+//FaucetCtx is like Faucet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) FaucetCtx(ctx context.Context, a AddrKeychain, amount int) (txhash string, err error) {
 	if !(api.Coin == "bcy" && api.Chain == "test") && !(api.Coin == "btc" && api.Chain == "test3") {
 		err = errors.New("Faucet: Cannot use Faucet unless on BlockCypher Testnet or Bitcoin Testnet3.")
 		return
@@ -125,7 +179,7 @@ func (api *API) Faucet(a AddrKeychain, amount int) (txhash string, err error) {
 		addr = a.Address
 	}
 	txref := make(map[string]string)
-	err = postResponse(u, &FauxAddr{addr, amount}, &txref)
+	err = api.postResponse(ctx, u, &FauxAddr{addr, amount}, &txref)
 	txhash = txref["tx_ref"]
 	return
 }
@@ -1,13 +1,21 @@
 package gobcy
 
+import "context"
+
 //GenAssetKeychain generates a public/private key pair, alongside
 //an associated OAPAddress for use in the Asset API.
 func (api *API) GenAssetKeychain() (pair AddrKeychain, err error) {
+	return api.GenAssetKeychainCtx(context.Background())
+}
+
+//GenAssetKeychainCtx is like GenAssetKeychain, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GenAssetKeychainCtx(ctx context.Context) (pair AddrKeychain, err error) {
 	u, err := api.buildURL("/oap/addrs", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, nil, &pair)
+	err = api.postResponse(ctx, u, nil, &pair)
 	return
 }
 
@@ -15,44 +23,68 @@ func (api *API) GenAssetKeychain() (pair AddrKeychain, err error) {
 //using a private key associated with a funded address
 //on the underlying blockchain.
 func (api *API) IssueAsset(issue OAPIssue) (tx OAPTX, err error) {
+	return api.IssueAssetCtx(context.Background(), issue)
+}
+
+//IssueAssetCtx is like IssueAsset, but uses ctx
+//for the underlying HTTP request.
+func (api *API) IssueAssetCtx(ctx context.Context, issue OAPIssue) (tx OAPTX, err error) {
 	u, err := api.buildURL("/oap/issue", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &issue, &tx)
+	err = api.postResponse(ctx, u, &issue, &tx)
 	return
 }
 
 //TransferAsset transfers previously issued assets onto a new
 //Open Asset Address, based on the assetid and OAPIssue.
 func (api *API) TransferAsset(issue OAPIssue, assetID string) (tx OAPTX, err error) {
+	return api.TransferAssetCtx(context.Background(), issue, assetID)
+}
+
+//TransferAssetCtx is like TransferAsset, but uses ctx
+//for the underlying HTTP request.
+func (api *API) TransferAssetCtx(ctx context.Context, issue OAPIssue, assetID string) (tx OAPTX, err error) {
 	u, err := api.buildURL("/oap/"+assetID+"/transfer", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &issue, &tx)
+	err = api.postResponse(ctx, u, &issue, &tx)
 	return
 }
 
 //ListAssetTXs lists the transaction hashes associated
 //with the given assetID.
 func (api *API) ListAssetTXs(assetID string) (txs []string, err error) {
+	return api.ListAssetTXsCtx(context.Background(), assetID)
+}
+
+//ListAssetTXsCtx is like ListAssetTXs, but uses ctx
+//for the underlying HTTP request.
+func (api *API) ListAssetTXsCtx(ctx context.Context, assetID string) (txs []string, err error) {
 	u, err := api.buildURL("/oap/"+assetID+"/txs", nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &txs)
+	err = api.getResponse(ctx, u, &txs)
 	return
 }
 
 //GetAssetTX returns a OAPTX associated with the given
 //assetID and transaction hash.
 func (api *API) GetAssetTX(assetID, hash string) (tx OAPTX, err error) {
+	return api.GetAssetTXCtx(context.Background(), assetID, hash)
+}
+
+//GetAssetTXCtx is like GetAssetTX, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetAssetTXCtx(ctx context.Context, assetID, hash string) (tx OAPTX, err error) {
 	u, err := api.buildURL("/oap/"+assetID+"/txs/"+hash, nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &tx)
+	err = api.getResponse(ctx, u, &tx)
 	return
 }
 
@@ -61,10 +93,16 @@ func (api *API) GetAssetTX(assetID, hash string) (tx OAPTX, err error) {
 //anything that would have represented "satoshis" now represents
 //"amount of asset."
 func (api *API) GetAssetAddr(assetID, oapAddr string) (addr Addr, err error) {
+	return api.GetAssetAddrCtx(context.Background(), assetID, oapAddr)
+}
+
+//GetAssetAddrCtx is like GetAssetAddr, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetAssetAddrCtx(ctx context.Context, assetID, oapAddr string) (addr Addr, err error) {
 	u, err := api.buildURL("/oap/"+assetID+"/addrs/"+oapAddr, nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &addr)
+	err = api.getResponse(ctx, u, &addr)
 	return
 }
@@ -0,0 +1,177 @@
+package gobcy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+//maxBatch is the largest number of objects
+//BlockCypher accepts in one batched request.
+const maxBatch = 100
+
+//GetAddrBalBatch is the batched version of GetAddrBal, looking up
+//many addresses with as few requests as BlockCypher allows. The
+//returned addrs and errs line up with hashes: for each address,
+//either addrs[i] is filled in or errs[i] explains why it isn't. err
+//is only set if a whole request fails, in which case the results
+//of earlier requests are still returned.
+func (api *API) GetAddrBalBatch(hashes []string, params map[string]string) (addrs []Addr, errs []error, err error) {
+	return api.GetAddrBalBatchCtx(context.Background(), hashes, params)
+}
+
+//GetAddrBalBatchCtx is like GetAddrBalBatch, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) GetAddrBalBatchCtx(ctx context.Context, hashes []string, params map[string]string) (addrs []Addr, errs []error, err error) {
+	addrs = make([]Addr, len(hashes))
+	errs, err = api.getBatch(ctx, "/addrs/", "/balance", hashes, params, addrDecoder(addrs))
+	return
+}
+
+//GetAddrBatch is the batched version of GetAddr,
+//returning results like GetAddrBalBatch.
+func (api *API) GetAddrBatch(hashes []string, params map[string]string) (addrs []Addr, errs []error, err error) {
+	return api.GetAddrBatchCtx(context.Background(), hashes, params)
+}
+
+//GetAddrBatchCtx is like GetAddrBatch, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) GetAddrBatchCtx(ctx context.Context, hashes []string, params map[string]string) (addrs []Addr, errs []error, err error) {
+	addrs = make([]Addr, len(hashes))
+	errs, err = api.getBatch(ctx, "/addrs/", "", hashes, params, addrDecoder(addrs))
+	return
+}
+
+//GetTXBatch is the batched version of GetTX,
+//returning results like GetAddrBalBatch.
+func (api *API) GetTXBatch(hashes []string, params map[string]string) (txs []TX, errs []error, err error) {
+	return api.GetTXBatchCtx(context.Background(), hashes, params)
+}
+
+//GetTXBatchCtx is like GetTXBatch, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) GetTXBatchCtx(ctx context.Context, hashes []string, params map[string]string) (txs []TX, errs []error, err error) {
+	txs = make([]TX, len(hashes))
+	errs, err = api.getBatch(ctx, "/txs/", "", hashes, params, func(raw []byte) ([]string, func(int), error) {
+		var tx TX
+		err := json.Unmarshal(raw, &tx)
+		return []string{tx.Hash}, func(i int) { txs[i] = tx }, err
+	})
+	return
+}
+
+//GetBlockBatch is the batched version of GetBlock, taking
+//block heights or hashes (which may be mixed) as ids and
+//returning results like GetAddrBalBatch.
+func (api *API) GetBlockBatch(ids []string, params map[string]string) (blocks []Block, errs []error, err error) {
+	return api.GetBlockBatchCtx(context.Background(), ids, params)
+}
+
+//GetBlockBatchCtx is like GetBlockBatch, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) GetBlockBatchCtx(ctx context.Context, ids []string, params map[string]string) (blocks []Block, errs []error, err error) {
+	blocks = make([]Block, len(ids))
+	errs, err = api.getBatch(ctx, "/blocks/", "", ids, params, func(raw []byte) ([]string, func(int), error) {
+		var block Block
+		err := json.Unmarshal(raw, &block)
+		return []string{block.Hash, strconv.Itoa(block.Height)}, func(i int) { blocks[i] = block }, err
+	})
+	return
+}
+
+//addrDecoder returns a batchDecoder filling addrs.
+func addrDecoder(addrs []Addr) batchDecoder {
+	return func(raw []byte) ([]string, func(int), error) {
+		var addr Addr
+		err := json.Unmarshal(raw, &addr)
+		return []string{addr.Address, addr.Wallet.Name, addr.HDWallet.Name}, func(i int) { addrs[i] = addr }, err
+	}
+}
+
+//batchDecoder decodes one object from a batched response,
+//returning the keys it may have been requested by and a
+//function storing it at an index of the results.
+type batchDecoder func(raw []byte) (keys []string, store func(i int), err error)
+
+//getBatch requests prefix+keys+suffix in chunks of maxBatch, with
+//keys separated by semicolons, and passes each returned object to
+//dec. Error objects and objects dec can't place are matched to the
+//remaining keys of their chunk in order.
+func (api *API) getBatch(ctx context.Context, prefix, suffix string, keys []string, params map[string]string, dec batchDecoder) (errs []error, err error) {
+	errs = make([]error, len(keys))
+	for start := 0; start < len(keys); start += maxBatch {
+		end := start + maxBatch
+		if end > len(keys) {
+			end = len(keys)
+		}
+		chunk := keys[start:end]
+		u, err := api.buildURL(prefix+strings.Join(chunk, ";")+suffix, params)
+		if err != nil {
+			return errs, err
+		}
+		var body json.RawMessage
+		if err = api.getResponse(ctx, u, &body); err != nil {
+			return errs, err
+		}
+		//a batch of one comes back as a single object
+		items := []json.RawMessage{body}
+		if b := bytes.TrimSpace(body); len(b) > 0 && b[0] == '[' {
+			if err = json.Unmarshal(b, &items); err != nil {
+				return errs, err
+			}
+		}
+		done := make([]bool, len(chunk))
+		var unplaced []error
+		for _, raw := range items {
+			var e struct {
+				Err string `json:"error"`
+			}
+			if json.Unmarshal(raw, &e) == nil && e.Err != "" {
+				unplaced = append(unplaced, &APIError{StatusCode: 200, Messages: []string{e.Err}, Body: raw, Path: api.redact(u.Path)})
+				continue
+			}
+			objKeys, store, err := dec(raw)
+			if err != nil {
+				unplaced = append(unplaced, err)
+				continue
+			}
+			i := matchPending(chunk, done, objKeys)
+			if i < 0 {
+				unplaced = append(unplaced, errors.New("Func getBatch: unexpected object in batch response"))
+				continue
+			}
+			store(start + i)
+			done[i] = true
+		}
+		for i := range chunk {
+			if done[i] {
+				continue
+			}
+			if len(unplaced) > 0 {
+				errs[start+i], unplaced = unplaced[0], unplaced[1:]
+			} else {
+				errs[start+i] = errors.New("Func getBatch: " + chunk[i] + " missing from batch response")
+			}
+		}
+	}
+	return
+}
+
+//matchPending returns the index of the first key not yet
+//done that equals one of objKeys, or -1 if there's none.
+func matchPending(keys []string, done []bool, objKeys []string) int {
+	for i, k := range keys {
+		if done[i] {
+			continue
+		}
+		for _, ok := range objKeys {
+			if ok != "" && ok == k {
+				return i
+			}
+		}
+	}
+	return -1
+}
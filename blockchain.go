@@ -1,6 +1,7 @@
 package gobcy
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"strconv"
@@ -9,40 +10,114 @@ import (
 //GetChain returns the current state of the
 //configured Coin/Chain.
 func (api *API) GetChain() (chain Blockchain, err error) {
+	return api.GetChainCtx(context.Background())
+}
+
+//GetChainCtx is like GetChain, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetChainCtx(ctx context.Context) (chain Blockchain, err error) {
 	u, err := api.buildURL("", nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &chain)
+	err = api.getResponse(ctx, u, &chain)
 	return
 }
 
 //GetBlock returns a Block based on either height
 //or hash. If both height and hash are sent, it will
-//throw an error.
+//throw an error. If hash is empty, height is used,
+//so height 0 returns the genesis block; GetBlockByID
+//avoids the ambiguity altogether.
+//See BlockQuery for its supported params.
 func (api *API) GetBlock(height int, hash string, params map[string]string) (block Block, err error) {
-	var u *url.URL
-	ustr := "/blocks/"
+	return api.GetBlockCtx(context.Background(), height, hash, params)
+}
+
+//GetBlockCtx is like GetBlock, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetBlockCtx(ctx context.Context, height int, hash string, params map[string]string) (block Block, err error) {
+	id := ByHeight(height)
 	if height != 0 && hash != "" {
-		err = errors.New("Func GetBlockPage: Cannot send both height and hash")
+		err = errors.New("Func GetBlock: Cannot send both height and hash")
 		return
-	} else if height != 0 {
-		ustr = ustr + strconv.Itoa(height)
 	} else if hash != "" {
-		ustr = ustr + hash
+		id = ByHash(hash)
+	}
+	return api.GetBlockByIDCtx(ctx, id, params)
+}
+
+//BlockID identifies a block either by height or by hash;
+//make one with ByHeight or ByHash. The zero BlockID is
+//the genesis block, at height 0.
+type BlockID struct {
+	height int
+	hash   string
+}
+
+//ByHeight returns the BlockID of the block at height.
+func ByHeight(height int) BlockID {
+	return BlockID{height: height}
+}
+
+//ByHash returns the BlockID of the block with hash.
+func ByHash(hash string) BlockID {
+	return BlockID{hash: hash}
+}
+
+//String returns the height or hash of id,
+//as used in BlockCypher URLs.
+func (id BlockID) String() string {
+	if id.hash != "" {
+		return id.hash
 	}
-	u, err = api.buildURL(ustr, params)
+	return strconv.Itoa(id.height)
+}
+
+//GetBlockByID returns the Block identified by id.
+//See BlockQuery for its supported params.
+func (api *API) GetBlockByID(id BlockID, params map[string]string) (block Block, err error) {
+	return api.GetBlockByIDCtx(context.Background(), id, params)
+}
+
+//GetBlockByIDCtx is like GetBlockByID, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetBlockByIDCtx(ctx context.Context, id BlockID, params map[string]string) (block Block, err error) {
+	u, err := api.buildURL("/blocks/"+id.String(), params)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &block)
+	err = api.getResponse(ctx, u, &block)
+	return
+}
+
+//GetBlockHeader returns the Block identified by id without
+//its TXids, asking BlockCypher for as few of them as it
+//allows. Use it when you only need a block's header data,
+//like its hash, height, time or PrevBlock.
+func (api *API) GetBlockHeader(id BlockID) (block Block, err error) {
+	return api.GetBlockHeaderCtx(context.Background(), id)
+}
+
+//GetBlockHeaderCtx is like GetBlockHeader, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetBlockHeaderCtx(ctx context.Context, id BlockID) (block Block, err error) {
+	block, err = api.GetBlockByIDCtx(ctx, id, map[string]string{"limit": "1"})
+	block.TXids, block.NextTXs = nil, ""
 	return
 }
 
 //GetBlockNextTXs returns the the next page of TXids based
 //on the NextTXs URL in this Block. If NextTXs is empty,
-//this will return an error.
+//this will return an error. Use IterBlockTXs to walk
+//every TXid instead.
 func (api *API) GetBlockNextTXs(this Block) (next Block, err error) {
+	return api.GetBlockNextTXsCtx(context.Background(), this)
+}
+
+//GetBlockNextTXsCtx is like GetBlockNextTXs, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetBlockNextTXsCtx(ctx context.Context, this Block) (next Block, err error) {
 	if this.NextTXs == "" {
 		err = errors.New("Func GetNextTXs: This Block doesn't have more transactions")
 		return
@@ -60,6 +135,6 @@ func (api *API) GetBlockNextTXs(this Block) (next Block, err error) {
 	for k := range query {
 		params[k] = query.Get(k)
 	}
-	next, err = api.GetBlock(0, this.Hash, params)
+	next, err = api.GetBlockCtx(ctx, 0, this.Hash, params)
 	return
 }
@@ -0,0 +1,170 @@
+package gobcy
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+//BlockTXIter iterates over the txids of a block, and optionally
+//their full TXs, fetching pages as needed. Use it like so:
+//	it := bc.IterBlockTXs(hash, nil)
+//	for it.Next() {
+//		txid := it.TXid()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type BlockTXIter struct {
+	api      *API
+	ctx      context.Context
+	hash     string
+	params   map[string]string
+	start    int
+	workers  int
+	txParams map[string]string
+	ids      []string
+	txs      []TX
+	id       string
+	tx       TX
+	done     bool
+	err      error
+}
+
+//IterBlockTXs returns a BlockTXIter over every txid of the block
+//with the given hash, in block order. Each page keeps the given
+//params (see BlockQuery): Limit sets the page size, and TXStart
+//the txid to start from.
+func (api *API) IterBlockTXs(hash string, params map[string]string) *BlockTXIter {
+	return api.IterBlockTXsCtx(context.Background(), hash, params)
+}
+
+//IterBlockTXsCtx is like IterBlockTXs, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) IterBlockTXsCtx(ctx context.Context, hash string, params map[string]string) *BlockTXIter {
+	it := &BlockTXIter{api: api, ctx: ctx, hash: hash, params: make(map[string]string)}
+	for k, v := range params {
+		it.params[k] = v
+	}
+	it.start, _ = strconv.Atoi(params["txstart"])
+	return it
+}
+
+//IterBlockTXsFull is like IterBlockTXs, but also fetches each
+//txid's TX with GetTX and txParams (see TXQuery), making up to
+//workers requests at once. TXs are still returned in block order;
+//the API's Limiter, if any, applies to every request.
+func (api *API) IterBlockTXsFull(hash string, params map[string]string, workers int, txParams map[string]string) *BlockTXIter {
+	return api.IterBlockTXsFullCtx(context.Background(), hash, params, workers, txParams)
+}
+
+//IterBlockTXsFullCtx is like IterBlockTXsFull, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) IterBlockTXsFullCtx(ctx context.Context, hash string, params map[string]string, workers int, txParams map[string]string) *BlockTXIter {
+	it := api.IterBlockTXsCtx(ctx, hash, params)
+	it.workers = workers
+	if it.workers < 1 {
+		it.workers = 1
+	}
+	it.txParams = txParams
+	return it
+}
+
+//Next advances to the next txid, returning false when
+//there are none left or an error occurred; see Err.
+func (it *BlockTXIter) Next() bool {
+	for len(it.ids) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		if it.err = it.fetch(); it.err != nil {
+			return false
+		}
+	}
+	it.id, it.ids = it.ids[0], it.ids[1:]
+	if it.txs != nil {
+		it.tx, it.txs = it.txs[0], it.txs[1:]
+	}
+	return true
+}
+
+//fetch gets the next page of txids, and their
+//TXs if the iterator hydrates them.
+func (it *BlockTXIter) fetch() (err error) {
+	params := make(map[string]string, len(it.params)+1)
+	for k, v := range it.params {
+		params[k] = v
+	}
+	params["txstart"] = strconv.Itoa(it.start)
+	block, err := it.api.GetBlockCtx(it.ctx, 0, it.hash, params)
+	if err != nil {
+		return
+	}
+	it.start += len(block.TXids)
+	it.done = block.NextTXs == "" || len(block.TXids) == 0
+	if it.workers > 0 {
+		if it.txs, err = it.hydrate(block.TXids); err != nil {
+			return
+		}
+	}
+	it.ids = block.TXids
+	return
+}
+
+//hydrate fetches the TXs of ids, it.workers at a time,
+//stopping at the first error.
+func (it *BlockTXIter) hydrate(ids []string) (txs []TX, err error) {
+	ctx, cancel := context.WithCancel(it.ctx)
+	defer cancel()
+	txs = make([]TX, len(ids))
+	errs := make([]error, len(ids))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < it.workers && w < len(ids); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if txs[i], errs[i] = it.api.GetTXCtx(ctx, ids[i], it.txParams); errs[i] != nil {
+					cancel()
+				}
+			}
+		}()
+	}
+	for i := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	//report the first error in block order that isn't
+	//just the cancellation caused by another
+	for _, e := range errs {
+		if e != nil && (err == nil || errors.Is(err, context.Canceled)) {
+			err = e
+		}
+	}
+	if err == nil {
+		err = it.ctx.Err()
+	}
+	return
+}
+
+//TXid returns the current txid.
+func (it *BlockTXIter) TXid() string {
+	return it.id
+}
+
+//TX returns the current TX, if the iterator
+//was made with IterBlockTXsFull.
+func (it *BlockTXIter) TX() TX {
+	return it.tx
+}
+
+//Err returns the error that stopped the iteration, if any.
+func (it *BlockTXIter) Err() error {
+	return it.err
+}
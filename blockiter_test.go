@@ -0,0 +1,61 @@
+package gobcy_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+)
+
+func TestIterBlockTXs(t *testing.T) {
+	srv := gobcytest.NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	keys, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	for i := 0; i < 24; i++ {
+		srv.Fund(keys.Address, int64(1000+i))
+	}
+	srv.Mine()
+	ch, err := bc.GetChain()
+	if err != nil {
+		t.Fatal("GetChain error encountered: ", err)
+	}
+	bl, err := bc.GetBlock(0, ch.Hash, map[string]string{"limit": "100"})
+	if err != nil {
+		t.Fatal("GetBlock error encountered: ", err)
+	}
+	var ids []string
+	it := bc.IterBlockTXs(ch.Hash, gobcy.BlockQuery{Limit: 7}.Params())
+	for it.Next() {
+		ids = append(ids, it.TXid())
+	}
+	if err = it.Err(); err != nil {
+		t.Fatal("IterBlockTXs error encountered: ", err)
+	}
+	if strings.Join(ids, ",") != strings.Join(bl.TXids, ",") {
+		t.Errorf("IterBlockTXs returned %d txids, expected the block's %d", len(ids), len(bl.TXids))
+	}
+	full := bc.IterBlockTXsFull(ch.Hash, gobcy.BlockQuery{TXStart: 5, Limit: 10}.Params(), 4, nil)
+	n := 5
+	for ; full.Next(); n++ {
+		if tx := full.TX(); tx.Hash != bl.TXids[n] || full.TXid() != tx.Hash {
+			t.Errorf("IterBlockTXsFull returned %s at %d, expected %s", tx.Hash, n, bl.TXids[n])
+		}
+	}
+	if err = full.Err(); err != nil || n != 25 {
+		t.Errorf("IterBlockTXsFull stopped at %d, %v; expected 25", n, err)
+	}
+	//cancellation stops the iteration
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	full = bc.IterBlockTXsFullCtx(ctx, ch.Hash, nil, 4, nil)
+	if full.Next() || !errors.Is(full.Err(), context.Canceled) {
+		t.Error("Expected context.Canceled iterating with a canceled context, got: ", full.Err())
+	}
+}
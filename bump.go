@@ -0,0 +1,253 @@
+package gobcy
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+//Bump is a fee bump made by BumpFee: an unconfirmed
+//TX, and the TX replacing it at a higher fee.
+type Bump struct {
+	Original    string  `json:"original"`
+	Replacement string  `json:"replacement"`
+	OldFee      big.Int `json:"old_fee"`
+	Fee         big.Int `json:"fee"`
+}
+
+//BumpFee replaces the unconfirmed TX hash with one paying
+//feeRate, in satoshis per virtual byte (see FeeEstimator),
+//and pushes it. The TX must signal replaceability (BIP-125)
+//through one of its input sequences, like a RawTX with an
+//input set to RBFSequence. The replacement spends the same
+//inputs and pays the same outputs, except for the change,
+//which pays the extra fee: the output paying one of the
+//inputs' scripts, or one of signer's keys. If the change left
+//is too small to be worth an output, it all goes to the fee.
+//The new fee must exceed the old one by at least 1 satoshi
+//per virtual byte of the replacement, or BumpFee fails.
+//The replacement is signed with signer, which must hold keys
+//for every input, and every signature a multisig input needs;
+//otherwise BumpFee fails, naming them, without pushing it.
+//Either TX may still confirm until one does; find out
+//which with BumpConfirmed or WaitBump.
+func (api *API) BumpFee(hash string, feeRate float64, signer Signer) (bump Bump, err error) {
+	return api.BumpFeeCtx(context.Background(), hash, feeRate, signer)
+}
+
+//BumpFeeCtx is like BumpFee, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) BumpFeeCtx(ctx context.Context, hash string, feeRate float64, signer Signer) (bump Bump, err error) {
+	params, err := ChainParams(api.Coin, api.Chain)
+	if err != nil {
+		return
+	}
+	orig, err := api.GetTXAllCtx(ctx, hash, map[string]string{"includeHex": "true"})
+	if err != nil {
+		return
+	}
+	if orig.Confirmations > 0 {
+		err = errors.New("Func BumpFee: TX " + hash + " is already confirmed")
+		return
+	}
+	rawDat, err := hex.DecodeString(orig.Hex)
+	if err != nil {
+		return
+	}
+	msg := wire.NewMsgTx(wire.TxVersion)
+	if err = msg.Deserialize(bytes.NewReader(rawDat)); err != nil {
+		return
+	}
+	if len(orig.Inputs) != len(msg.TxIn) {
+		err = errors.New("Func BumpFee: TX " + hash + " doesn't match its hex")
+		return
+	}
+	if !signalsRBF(msg) {
+		err = errors.New("Func BumpFee: TX " + hash + " doesn't signal replaceability (BIP-125)")
+		return
+	}
+	raw := &RawTX{params: params, msg: wire.NewMsgTx(msg.Version)}
+	raw.msg.LockTime = msg.LockTime
+	for i, in := range msg.TxIn {
+		prev, err := signedPrev(in, int64(orig.Inputs[i].OutputValue))
+		if err != nil {
+			return bump, errors.New("Func BumpFee: input " + strconv.Itoa(i) + ": " + err.Error())
+		}
+		raw.msg.AddTxIn(wire.NewTxIn(&in.PreviousOutPoint, nil, nil))
+		raw.msg.TxIn[i].Sequence = in.Sequence
+		raw.prevs = append(raw.prevs, prev)
+	}
+	change, err := changeOutput(msg, raw.prevs, signer)
+	if err != nil {
+		return
+	}
+	for _, out := range msg.TxOut {
+		raw.msg.AddTxOut(wire.NewTxOut(out.Value, out.PkScript))
+	}
+	bump.Original, bump.OldFee = hash, raw.Fee()
+	bump.Fee = FeeFor(feeRate, raw.VSize())
+	//BIP-125 rule 4: the replacement must also pay
+	//for its own relay at the incremental fee rate
+	min := FeeFor(incrementalRelayFee, raw.VSize())
+	min.Add(&min, &bump.OldFee)
+	if bump.Fee.Cmp(&min) < 0 {
+		err = errors.New("Func BumpFee: a fee rate of " + strconv.FormatFloat(feeRate, 'f', -1, 64) +
+			" pays a fee of " + bump.Fee.String() + ", but replacing TX " + hash + " takes at least " + min.String())
+		return
+	}
+	out := raw.msg.TxOut[change]
+	out.Value -= bump.Fee.Int64() - bump.OldFee.Int64()
+	switch {
+	case out.Value < 0:
+		err = errors.New("Func BumpFee: the change of TX " + hash + " can't pay a fee of " + bump.Fee.String())
+		return
+	case out.Value < dustLimit:
+		raw.msg.TxOut = append(raw.msg.TxOut[:change], raw.msg.TxOut[change+1:]...)
+		bump.Fee = raw.Fee()
+	}
+	if err = raw.SignWith(signer); err != nil {
+		err = errors.New("Func BumpFee: " + err.Error())
+		return
+	}
+	if _, err = api.PushTXCtx(ctx, raw.Hex()); err != nil {
+		return
+	}
+	bump.Replacement = raw.Hash()
+	return
+}
+
+//incrementalRelayFee is the fee rate, in satoshis per
+//virtual byte, by which a replacement must outbid the TX
+//it replaces (BIP-125 rule 4), as nodes relay by default.
+const incrementalRelayFee = 1
+
+//signalsRBF returns whether one of the sequences of msg
+//signals replaceability (BIP-125).
+func signalsRBF(msg *wire.MsgTx) bool {
+	for _, in := range msg.TxIn {
+		if in.Sequence <= RBFSequence {
+			return true
+		}
+	}
+	return false
+}
+
+//signedPrev returns the output spent by the signed input in,
+//worth value, as its signature script and witness show it.
+func signedPrev(in *wire.TxIn, value int64) (prev rawPrev, err error) {
+	prev = rawPrev{value: value, sigs: make(map[int][]byte)}
+	pushes, err := txscript.PushedData(in.SignatureScript)
+	if err != nil {
+		return
+	}
+	var redeem []byte
+	if len(pushes) > 0 {
+		redeem = pushes[len(pushes)-1]
+	}
+	switch {
+	case len(in.Witness) == 2 && len(pushes) == 0:
+		prev.script = append([]byte{txscript.OP_0, txscript.OP_DATA_20}, btcutil.Hash160(in.Witness[1])...)
+	case len(in.Witness) == 2 && len(pushes) == 1:
+		prev.script, err = p2shScript(redeem)
+	case len(in.Witness) == 0 && len(pushes) == 2 && !isMultisig(redeem):
+		prev.script, err = txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+			AddData(btcutil.Hash160(redeem)).AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+	case len(in.Witness) == 0 && isMultisig(redeem):
+		prev.redeem = redeem
+		_, prev.n, _ = txscript.CalcMultiSigStats(redeem)
+		keys, _ := txscript.PushedData(redeem)
+		for _, k := range keys {
+			if len(k) == 33 || len(k) == 65 {
+				prev.pubkeys = append(prev.pubkeys, k)
+			}
+		}
+		prev.script, err = p2shScript(redeem)
+	default:
+		err = errors.New("unsupported script type")
+	}
+	return
+}
+
+//isMultisig returns whether script is a multisig script.
+func isMultisig(script []byte) bool {
+	ok, err := txscript.IsMultisigScript(script)
+	return ok && err == nil
+}
+
+//p2shScript returns the P2SH script of redeem.
+func p2shScript(redeem []byte) ([]byte, error) {
+	return txscript.NewScriptBuilder().AddOp(txscript.OP_HASH160).
+		AddData(btcutil.Hash160(redeem)).AddOp(txscript.OP_EQUAL).Script()
+}
+
+//changeOutput returns the index of the last output of msg
+//paying one of the scripts of prevs, or one of signer's keys.
+func changeOutput(msg *wire.MsgTx, prevs []rawPrev, signer Signer) (change int, err error) {
+	pubs, err := signer.PubKeys()
+	if err != nil {
+		return
+	}
+	change = -1
+	for j, out := range msg.TxOut {
+		for _, prev := range prevs {
+			if bytes.Equal(out.PkScript, prev.script) {
+				change = j
+			}
+		}
+		if keys, _ := scriptKeys(out.PkScript, nil, pubs); len(keys) > 0 {
+			change = j
+		}
+	}
+	if change < 0 {
+		err = errors.New("Func BumpFee: TX " + msg.TxHash().String() + " has no change output to pay the fee")
+	}
+	return
+}
+
+//BumpConfirmed returns the hash of the TX of bump that's
+//confirmed, its Original or its Replacement, or "" if
+//neither is yet.
+func (api *API) BumpConfirmed(bump Bump) (hash string, err error) {
+	return api.BumpConfirmedCtx(context.Background(), bump)
+}
+
+//BumpConfirmedCtx is like BumpConfirmed, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) BumpConfirmedCtx(ctx context.Context, bump Bump) (hash string, err error) {
+	for _, h := range []string{bump.Replacement, bump.Original} {
+		tx, err := api.GetTXCtx(ctx, h, map[string]string{"limit": "1"})
+		if IsNotFound(err) {
+			//BlockCypher may forget the TX that lost
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if tx.Confirmations > 0 {
+			return h, nil
+		}
+	}
+	return
+}
+
+//WaitBump polls BumpConfirmed every interval until a TX of
+//bump confirms, and returns its hash. It runs until then,
+//ctx is done, or an error occurs, and returns that error.
+func (api *API) WaitBump(ctx context.Context, bump Bump, interval time.Duration) (hash string, err error) {
+	for {
+		if hash, err = api.BumpConfirmedCtx(ctx, bump); err != nil || hash != "" {
+			return
+		}
+		if err = sleepCtx(ctx, interval); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package gobcy_test
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+func TestBumpFee(t *testing.T) {
+	srv := gobcytest.NewServer("btc", "test3")
+	defer srv.Close()
+	bc := srv.API("token")
+	params := &chaincfg.TestNet3Params
+	signer, partial := gobcy.NewKeySigner(), gobcy.NewKeySigner()
+	var pubs []string
+	var msAddrs []*btcutil.AddressPubKey
+	for i := 0; i < 2; i++ {
+		priv, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer.Add(priv, true)
+		if i == 0 {
+			partial.Add(priv, true)
+		}
+		pub := priv.PubKey().SerializeCompressed()
+		addr, _ := btcutil.NewAddressPubKey(pub, params)
+		pubs, msAddrs = append(pubs, hex.EncodeToString(pub)), append(msAddrs, addr)
+	}
+	keys, _ := signer.PubKeys()
+	redeem, _ := txscript.MultiSigScript(msAddrs, 2)
+	p2pkh, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(keys[0]), params)
+	p2wpkh, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(keys[1]), params)
+	multisig, _ := btcutil.NewAddressScriptHash(redeem, params)
+	from := []string{p2pkh.String(), p2wpkh.String(), multisig.String()}
+	for _, addr := range from {
+		srv.Fund(addr, 1e5)
+	}
+	srv.Mine()
+	dest, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	refs, err := bc.GetUnspent(from, 1)
+	if err != nil || len(refs) != 3 {
+		t.Fatalf("GetUnspent returned %d refs, error %v", len(refs), err)
+	}
+	//build returns the TX paying extra on top of a fee of 10
+	//satoshis per virtual byte, with that fee, once signed
+	build := func(extra int64) (raw *gobcy.RawTX, fee big.Int) {
+		raw, err := bc.NewRawTX()
+		if err != nil {
+			t.Fatal("NewRawTX error encountered: ", err)
+		}
+		for _, ref := range refs {
+			if ref.Address == multisig.String() {
+				err = raw.AddMultisigInput(ref, 2, pubs)
+			} else {
+				err = raw.AddInput(ref)
+			}
+			if err != nil {
+				t.Fatal("AddInput error encountered: ", err)
+			}
+		}
+		if err = raw.SetSequence(0, gobcy.RBFSequence); err != nil {
+			t.Fatal("SetSequence error encountered: ", err)
+		}
+		if err = raw.AddOutput(dest.Address, *big.NewInt(15e4)); err != nil {
+			t.Fatal("AddOutput error encountered: ", err)
+		}
+		//the fee of the TX with its change output
+		fee = gobcy.FeeFor(10, raw.VSize()+31)
+		fee.Add(&fee, big.NewInt(extra))
+		if err = raw.AddOutput(p2wpkh.String(), *big.NewInt(3e5 - 15e4 - fee.Int64())); err != nil {
+			t.Fatal("AddOutput error encountered: ", err)
+		}
+		if err = raw.SignWith(signer); err != nil {
+			t.Fatal("SignWith error encountered: ", err)
+		}
+		return
+	}
+	raw, low := build(0)
+	orig, err := bc.PushTX(raw.Hex())
+	if err != nil {
+		t.Fatal("PushTX error encountered: ", err)
+	}
+	if _, err = bc.BumpFee(orig.Trans.Hash, 5, signer); err == nil {
+		t.Error("Expected error bumping to a lower fee rate")
+	}
+	//a replacement must also pay for its own relay (BIP-125)
+	if _, err = bc.BumpFee(orig.Trans.Hash, 10.5, signer); err == nil {
+		t.Error("Expected error bumping by less than the incremental relay fee")
+	}
+	cheap, _ := build(int64(raw.VSize() / 2))
+	if _, err = bc.PushTX(cheap.Hex()); err == nil {
+		t.Error("Expected error pushing a replacement not paying for its relay")
+	}
+	if _, err = bc.BumpFee(orig.Trans.Hash, 40, gobcy.NewKeySigner()); err == nil {
+		t.Error("Expected error bumping without the keys of the TX")
+	}
+	//one key of the multisig input isn't enough, and
+	//nothing is pushed
+	if _, err = bc.BumpFee(orig.Trans.Hash, 40, partial); err == nil || !strings.Contains(err.Error(), "2 (1 of 2)") {
+		t.Errorf("Expected error naming the partly signed multisig input, got: %v", err)
+	}
+	if tx, err := bc.GetTX(orig.Trans.Hash, nil); err != nil || tx.DoubleSpend {
+		t.Errorf("GetTX returned the original TX double spent %v, error %v", tx.DoubleSpend, err)
+	}
+	bump, err := bc.BumpFee(orig.Trans.Hash, 40, signer)
+	if err != nil {
+		t.Fatal("BumpFee error encountered: ", err)
+	}
+	if bump.Original != orig.Trans.Hash || bump.OldFee.Cmp(&low) != 0 || bump.Fee.Int64() <= 3*low.Int64() {
+		t.Errorf("BumpFee returned %+v, expected a fee four times %v", bump, &low)
+	}
+	replacement, err := bc.GetTX(bump.Replacement, nil)
+	if err != nil {
+		t.Fatal("GetTX error encountered: ", err)
+	}
+	if !replacement.DoubleSpend || replacement.DoubleOf != bump.Original || replacement.Fees.Cmp(&bump.Fee) != 0 {
+		t.Errorf("GetTX returned a replacement double spending %q with fees %v", replacement.DoubleOf, &replacement.Fees)
+	}
+	if replacement.Outputs[0].Value.Int64() != 15e4 {
+		t.Errorf("The replacement pays %v, expected 150000", &replacement.Outputs[0].Value)
+	}
+	if hash, err := bc.BumpConfirmed(bump); err != nil || hash != "" {
+		t.Errorf("BumpConfirmed returned %q, error %v, expected neither confirmed", hash, err)
+	}
+	srv.Mine()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if hash, err := bc.WaitBump(ctx, bump, 10*time.Millisecond); err != nil || hash != bump.Replacement {
+		t.Errorf("WaitBump returned %q, error %v, expected the replacement", hash, err)
+	}
+	if _, err = bc.BumpFee(bump.Replacement, 80, signer); err == nil {
+		t.Error("Expected error bumping a confirmed TX")
+	}
+	//a TX made by NewTX, with and without signaling
+	keychain := fundedKeys(t, srv, bc, 3e5)
+	ks := gobcy.NewKeySigner()
+	if err = ks.AddHex(keychain.Private); err != nil {
+		t.Fatal("AddHex error encountered: ", err)
+	}
+	req := gobcy.TempNewTX(keychain.Address, dest.Address, *big.NewInt(1e5))
+	req.Preference = "low"
+	skel, err := bc.NewTX(req, false)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = skel.SignWith(ks); err != nil {
+		t.Fatal("SignWith error encountered: ", err)
+	}
+	if skel, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+	if _, err = bc.BumpFee(skel.Trans.Hash, 40, ks); err == nil {
+		t.Error("Expected error bumping a TX not signaling replaceability")
+	}
+	fees := skel.Trans.Fees.Int64()
+	srv.Mine()
+	req = gobcy.TempNewTX(keychain.Address, dest.Address, *big.NewInt(1e5))
+	req.Preference, req.Inputs[0].Sequence = "low", gobcy.RBFSequence
+	if skel, err = bc.NewTX(req, false); err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = skel.SignWith(ks); err != nil {
+		t.Fatal("SignWith error encountered: ", err)
+	}
+	if skel, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+	if bump, err = bc.BumpFee(skel.Trans.Hash, 40, ks); err != nil {
+		t.Fatal("BumpFee error encountered: ", err)
+	}
+	original, err := bc.GetTX(bump.Original, nil)
+	if err != nil || !original.DoubleSpend {
+		t.Errorf("GetTX returned the replaced TX without DoubleSpend, error %v", err)
+	}
+	bal, err := bc.GetAddrBal(keychain.Address, nil)
+	if err != nil {
+		t.Fatal("GetAddrBal error encountered: ", err)
+	}
+	if want := 3e5 - 2e5 - fees - bump.Fee.Int64(); bal.FinalBalance.Int64() != want {
+		t.Errorf("GetAddrBal returned final balance %v, expected %d", &bal.FinalBalance, want)
+	}
+}
@@ -0,0 +1,405 @@
+package gobcy
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+//CoinStrategy is how SelectCoins chooses which
+//unspent outputs a transaction spends.
+type CoinStrategy int
+
+const (
+	//BranchAndBound looks for coins adding up to the amount
+	//and fee closely enough to need no change, falling back
+	//to LargestFirst if there are none.
+	BranchAndBound CoinStrategy = iota
+	//LargestFirst spends the largest coins first,
+	//so the transaction has as few inputs as possible.
+	LargestFirst
+	//OldestFirst spends the most confirmed coins first.
+	OldestFirst
+	//AvoidMixing spends all the coins of as few addresses as
+	//possible, so a transaction links no more addresses than
+	//it must, and leaves no coins of a spent address behind.
+	//It groups coins by their Address, as GetUnspent sets it.
+	AvoidMixing
+)
+
+//bnbTries caps the subsets BranchAndBound tries.
+const bnbTries = 100000
+
+//dustLimit is the smallest change output SelectCoins
+//makes, below which change goes to the fee instead.
+const dustLimit = 546
+
+//CoinOptions configures SelectCoins.
+type CoinOptions struct {
+	Strategy CoinStrategy
+	//FeeRate is the fee rate in satoshis per virtual
+	//byte, like one of a FeeEstimator.
+	FeeRate float64
+	//MinConfirmations leaves out coins with
+	//fewer confirmations.
+	MinConfirmations int
+	//InputType is the script type of every coin, as accepted
+	//by EstimateVSize; it's needed for multisig coins. If
+	//empty, each coin's type is read from its Script, and
+	//coins without one are taken to be "pay-to-pubkey-hash".
+	InputType string
+	//Outputs are the script types of the outputs paid,
+	//by default a single "pay-to-pubkey-hash".
+	Outputs []string
+	//ChangeType is the script type of the change
+	//output, by default "pay-to-pubkey-hash".
+	ChangeType string
+}
+
+//CoinSelection is the result of SelectCoins.
+type CoinSelection struct {
+	Coins []TXRef
+	//Total is the value of Coins.
+	Total big.Int
+	Fee   big.Int
+	//Change is the value of the change output, or 0
+	//if there is none and the excess goes to Fee.
+	Change big.Int
+}
+
+//coin is an unspent output SelectCoins may spend: its
+//value, script type, and the fee of spending it.
+type coin struct {
+	ref        TXRef
+	value      int64
+	scriptType string
+	cost       float64
+}
+
+//effective returns the value c adds to a TX once
+//the fee of spending it is paid.
+func (c coin) effective() float64 {
+	return float64(c.value) - c.cost
+}
+
+//GetUnspent returns the unspent outputs of addrs, with at
+//least minConf confirmations, as TXRefs for SelectCoins and
+//RawTX.AddInput. They include their scripts, and their Address
+//is set to the address they were listed for.
+func (api *API) GetUnspent(addrs []string, minConf int) (refs []TXRef, err error) {
+	return api.GetUnspentCtx(context.Background(), addrs, minConf)
+}
+
+//GetUnspentCtx is like GetUnspent, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) GetUnspentCtx(ctx context.Context, addrs []string, minConf int) (refs []TXRef, err error) {
+	params := AddrQuery{UnspentOnly: true, IncludeScript: true, Confirmations: minConf}.Params()
+	for _, a := range addrs {
+		it := api.IterAddrCtx(ctx, a, params)
+		for it.Next() {
+			ref := it.TXRef()
+			if ref.TXOutputN < 0 || ref.Spent {
+				continue
+			}
+			if ref.Address == "" {
+				ref.Address = a
+			}
+			refs = append(refs, ref)
+		}
+		if err = it.Err(); err != nil {
+			return
+		}
+	}
+	return
+}
+
+//SelectCoins chooses which of refs, unspent outputs like those
+//returned by GetUnspent, to spend to pay amount at opts.FeeRate,
+//following opts.Strategy. Spent outputs, outputs with fewer than
+//opts.MinConfirmations confirmations, and outputs worth less than
+//the fee of spending them are left out. To spend the selection
+//with NewTX, use its Inputs and Fee:
+//	sel, err := gobcy.SelectCoins(refs, *big.NewInt(45000), gobcy.CoinOptions{FeeRate: fe.Medium})
+//	req := gobcy.TX{Inputs: sel.Inputs(), Outputs: outputs, Fees: sel.Fee, ChangeAddress: change}
+//	skel, err := bc.NewTX(req, false)
+//or build it locally by passing each of its Coins to RawTX.AddInput,
+//adding a change output of its Change if that's not 0.
+func SelectCoins(refs []TXRef, amount big.Int, opts CoinOptions) (sel CoinSelection, err error) {
+	if opts.FeeRate < 0 {
+		err = errors.New("Func SelectCoins: negative fee rate")
+		return
+	}
+	if amount.Sign() <= 0 {
+		err = errors.New("Func SelectCoins: the amount must be positive")
+		return
+	}
+	if len(opts.Outputs) == 0 {
+		opts.Outputs = []string{"pay-to-pubkey-hash"}
+	}
+	if opts.ChangeType == "" {
+		opts.ChangeType = "pay-to-pubkey-hash"
+	}
+	changeSize, err := outputSize(opts.ChangeType)
+	if err != nil {
+		return
+	}
+	if _, err = EstimateVSize(nil, opts.Outputs); err != nil {
+		return
+	}
+	coins, err := spendable(refs, opts)
+	if err != nil {
+		return
+	}
+	//change is only worth making if it's worth more than the
+	//fees of making and spending it, if the latter is known
+	spendSize, wit, _ := inputSize(opts.ChangeType)
+	changeCost := opts.FeeRate * (float64(changeSize) + float64(spendSize*4+wit)/4)
+	s := selector{coins: coins, amount: amount.Int64(), opts: opts, changeCost: changeCost}
+	var chosen []coin
+	switch opts.Strategy {
+	case BranchAndBound:
+		if chosen = s.branchAndBound(); chosen == nil {
+			chosen = s.accumulate(largestFirst(coins))
+		}
+	case LargestFirst:
+		chosen = s.accumulate(largestFirst(coins))
+	case OldestFirst:
+		chosen = s.accumulate(oldestFirst(coins))
+	case AvoidMixing:
+		chosen = s.avoidMixing()
+	default:
+		err = errors.New("Func SelectCoins: unknown strategy " + strconv.Itoa(int(opts.Strategy)))
+		return
+	}
+	if chosen == nil {
+		var total int64
+		for _, c := range coins {
+			total += c.value
+		}
+		err = errors.New("Func SelectCoins: not enough funds: " + strconv.Itoa(len(coins)) + " spendable coins worth " +
+			strconv.FormatInt(total, 10) + " can't pay " + amount.String() + " and its fee")
+		return
+	}
+	return s.finish(chosen), nil
+}
+
+//spendable returns the coins of refs that opts allows
+//spending, each once, with their script types and costs.
+func spendable(refs []TXRef, opts CoinOptions) (coins []coin, err error) {
+	seen := make(map[string]bool)
+	for _, ref := range refs {
+		key := ref.TXHash + "/" + strconv.Itoa(ref.TXOutputN)
+		if ref.TXOutputN < 0 || ref.Spent || ref.DoubleSpend || ref.Confirmations < opts.MinConfirmations || seen[key] {
+			continue
+		}
+		seen[key] = true
+		c := coin{ref: ref, value: ref.Value.Int64(), scriptType: opts.InputType}
+		if c.scriptType == "" {
+			c.scriptType = refScriptType(ref)
+		}
+		size, wit, err := inputSize(c.scriptType)
+		if err != nil {
+			return nil, err
+		}
+		c.cost = opts.FeeRate * float64(size*4+wit) / 4
+		if c.effective() > 0 {
+			coins = append(coins, c)
+		}
+	}
+	return
+}
+
+//refScriptType returns the script type of the input spending
+//ref, as read from its Script, assuming P2SH is P2SH-P2WPKH.
+func refScriptType(ref TXRef) string {
+	script, _ := hex.DecodeString(ref.Script)
+	switch {
+	case txscript.IsPayToWitnessPubKeyHash(script):
+		return "pay-to-witness-pubkey-hash"
+	case txscript.IsPayToScriptHash(script):
+		return "pay-to-script-hash"
+	}
+	return "pay-to-pubkey-hash"
+}
+
+//largestFirst returns coins sorted by descending value.
+func largestFirst(coins []coin) []coin {
+	sorted := append([]coin(nil), coins...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].value > sorted[j].value
+	})
+	return sorted
+}
+
+//oldestFirst returns coins sorted by descending
+//confirmations, then ascending block height.
+func oldestFirst(coins []coin) []coin {
+	sorted := append([]coin(nil), coins...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i].ref, sorted[j].ref
+		if a.Confirmations != b.Confirmations {
+			return a.Confirmations > b.Confirmations
+		}
+		return a.BlockHeight < b.BlockHeight
+	})
+	return sorted
+}
+
+//selector implements the strategies of SelectCoins.
+type selector struct {
+	coins      []coin
+	amount     int64
+	opts       CoinOptions
+	changeCost float64
+}
+
+//fee returns the fee of a TX spending chosen,
+//with a change output if change is true.
+func (s *selector) fee(chosen []coin, change bool) int64 {
+	var inputs []string
+	for _, c := range chosen {
+		inputs = append(inputs, c.scriptType)
+	}
+	outputs := s.opts.Outputs
+	if change {
+		outputs = append(append([]string(nil), outputs...), s.opts.ChangeType)
+	}
+	vsize, _ := EstimateVSize(inputs, outputs)
+	fee := FeeFor(s.opts.FeeRate, vsize)
+	return fee.Int64()
+}
+
+//covers returns whether chosen pays the amount and
+//the fee of a TX spending them without change.
+func (s *selector) covers(chosen []coin) bool {
+	var total int64
+	for _, c := range chosen {
+		total += c.value
+	}
+	return total >= s.amount+s.fee(chosen, false)
+}
+
+//accumulate returns the first of coins that cover the
+//amount and fee, or nil if all of them don't.
+func (s *selector) accumulate(coins []coin) []coin {
+	for i := range coins {
+		if s.covers(coins[:i+1]) {
+			return coins[:i+1]
+		}
+	}
+	return nil
+}
+
+//branchAndBound returns the coins whose effective values add
+//up to the amount and the fee of the TX without inputs, going
+//over by less than the cost of change; of those it finds, the
+//ones going over by least. It returns nil if it finds none.
+func (s *selector) branchAndBound() []coin {
+	coins := largestFirst(s.coins)
+	sort.SliceStable(coins, func(i, j int) bool {
+		return coins[i].effective() > coins[j].effective()
+	})
+	base, _ := EstimateVSize(nil, s.opts.Outputs)
+	target := float64(s.amount) + s.opts.FeeRate*float64(base)
+	rest := make([]float64, len(coins)+1)
+	for i := len(coins) - 1; i >= 0; i-- {
+		rest[i] = rest[i+1] + coins[i].effective()
+	}
+	var best []coin
+	bestSum := math.Inf(1)
+	var picked []coin
+	tries := 0
+	var search func(i int, sum float64)
+	search = func(i int, sum float64) {
+		tries++
+		if tries > bnbTries || sum > target+s.changeCost || sum+rest[i] < target {
+			return
+		}
+		if sum >= target {
+			if sum < bestSum && s.covers(picked) {
+				best, bestSum = append([]coin(nil), picked...), sum
+			}
+			return
+		}
+		if i == len(coins) {
+			return
+		}
+		picked = append(picked, coins[i])
+		search(i+1, sum+coins[i].effective())
+		picked = picked[:len(picked)-1]
+		search(i+1, sum)
+	}
+	search(0, 0)
+	return best
+}
+
+//avoidMixing returns the coins of the address whose coins
+//cover the amount and fee with the smallest total, or if none
+//does alone, those of the addresses with the largest totals.
+func (s *selector) avoidMixing() []coin {
+	var addrs []string
+	groups := make(map[string][]coin)
+	for _, c := range s.coins {
+		if _, ok := groups[c.ref.Address]; !ok {
+			addrs = append(addrs, c.ref.Address)
+		}
+		groups[c.ref.Address] = append(groups[c.ref.Address], c)
+	}
+	total := func(a string) (t float64) {
+		for _, c := range groups[a] {
+			t += c.effective()
+		}
+		return
+	}
+	sort.SliceStable(addrs, func(i, j int) bool {
+		return total(addrs[i]) > total(addrs[j])
+	})
+	for i := len(addrs) - 1; i >= 0; i-- {
+		if s.covers(groups[addrs[i]]) {
+			return groups[addrs[i]]
+		}
+	}
+	var chosen []coin
+	for _, a := range addrs {
+		chosen = append(chosen, groups[a]...)
+		if s.covers(chosen) {
+			return chosen
+		}
+	}
+	return nil
+}
+
+//finish returns the CoinSelection of chosen, which cover
+//the amount and fee, making change if it's worth it.
+func (s *selector) finish(chosen []coin) (sel CoinSelection) {
+	var total int64
+	for _, c := range chosen {
+		total += c.value
+		sel.Coins = append(sel.Coins, c.ref)
+	}
+	sel.Total.SetInt64(total)
+	fee := s.fee(chosen, true)
+	change := total - s.amount - fee
+	if change < dustLimit || float64(change) <= s.changeCost {
+		fee, change = total-s.amount, 0
+	}
+	sel.Fee.SetInt64(fee)
+	sel.Change.SetInt64(change)
+	return
+}
+
+//Inputs returns the TXInputs spending the Coins of a
+//CoinSelection by outpoint, for the Inputs of a TX
+//passed to NewTX.
+func (sel CoinSelection) Inputs() (inputs []TXInput) {
+	for _, ref := range sel.Coins {
+		inputs = append(inputs, TXInput{PrevHash: ref.TXHash, OutputIndex: ref.TXOutputN})
+	}
+	return
+}
@@ -0,0 +1,143 @@
+package gobcy_test
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+)
+
+func TestSelectCoins(t *testing.T) {
+	srv := gobcytest.NewServer("btc", "test3")
+	defer srv.Close()
+	bc := srv.API("token")
+	a, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	b, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	for _, v := range []int64{1e4, 2e4, 5e4} {
+		srv.Fund(a.Address, v)
+		srv.Mine()
+	}
+	srv.Fund(b.Address, 3e4)
+	srv.Mine()
+	srv.Fund(a.Address, 1e5)
+	refs, err := bc.GetUnspent([]string{a.Address, b.Address}, 0)
+	if err != nil || len(refs) != 5 {
+		t.Fatalf("GetUnspent returned %d refs, error %v, expected 5", len(refs), err)
+	}
+	values := func(sel gobcy.CoinSelection) (vs []int64) {
+		for _, ref := range sel.Coins {
+			vs = append(vs, ref.Value.Int64())
+		}
+		sort.Slice(vs, func(i, j int) bool { return vs[i] < vs[j] })
+		return
+	}
+	opts := gobcy.CoinOptions{Strategy: gobcy.LargestFirst, FeeRate: 10, MinConfirmations: 1}
+	sel, err := gobcy.SelectCoins(refs, *big.NewInt(6e4), opts)
+	if err != nil {
+		t.Fatal("SelectCoins error encountered: ", err)
+	}
+	if vs := values(sel); fmt.Sprint(vs) != "[30000 50000]" {
+		t.Errorf("LargestFirst selected %v, expected 50000 and 30000", vs)
+	}
+	if sel.Fee.Int64() != 3760 || sel.Change.Int64() != 8e4-6e4-3760 {
+		t.Errorf("LargestFirst returned fee %v and change %v, expected 3760 and 16240", &sel.Fee, &sel.Change)
+	}
+	opts.Strategy = gobcy.OldestFirst
+	if sel, err = gobcy.SelectCoins(refs, *big.NewInt(6e4), opts); err != nil {
+		t.Fatal("SelectCoins error encountered: ", err)
+	}
+	if vs := values(sel); fmt.Sprint(vs) != "[10000 20000 50000]" {
+		t.Errorf("OldestFirst selected %v, expected [10000 20000 50000]", vs)
+	}
+	opts.Strategy = gobcy.AvoidMixing
+	if sel, err = gobcy.SelectCoins(refs, *big.NewInt(2e4), opts); err != nil {
+		t.Fatal("SelectCoins error encountered: ", err)
+	}
+	if vs := values(sel); fmt.Sprint(vs) != "[30000]" {
+		t.Errorf("AvoidMixing selected %v, expected the coin of the address covering the amount", vs)
+	}
+	if sel, err = gobcy.SelectCoins(refs, *big.NewInt(6e4), opts); err != nil {
+		t.Fatal("SelectCoins error encountered: ", err)
+	}
+	if vs := values(sel); fmt.Sprint(vs) != "[10000 20000 50000]" {
+		t.Errorf("AvoidMixing selected %v, expected every confirmed coin of one address", vs)
+	}
+	if _, err = gobcy.SelectCoins(refs, *big.NewInt(2e5), opts); err == nil {
+		t.Error("Expected error selecting coins without enough confirmed funds")
+	}
+	//10000+20000 pays 26580 and the fee of 342 vbytes exactly
+	opts.Strategy = gobcy.BranchAndBound
+	if sel, err = gobcy.SelectCoins(refs, *big.NewInt(26580), opts); err != nil {
+		t.Fatal("SelectCoins error encountered: ", err)
+	}
+	if vs := values(sel); fmt.Sprint(vs) != "[10000 20000]" || sel.Fee.Int64() != 3420 || sel.Change.Sign() != 0 {
+		t.Errorf("BranchAndBound selected %v with fee %v and change %v, expected 10000 and 20000 without change", vs, &sel.Fee, &sel.Change)
+	}
+	dest, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	req := gobcy.TempNewTX("", dest.Address, *big.NewInt(26580))
+	req.Inputs, req.Fees = sel.Inputs(), sel.Fee
+	skel, err := bc.NewTX(req, false)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if len(skel.Trans.Outputs) != 1 || skel.Trans.Fees.Int64() != 3420 {
+		t.Errorf("NewTX returned %d outputs and fees %v, expected 1 and 3420", len(skel.Trans.Outputs), &skel.Trans.Fees)
+	}
+	if err = skel.Sign([]string{a.Private}); err != nil {
+		t.Fatal("Sign error encountered: ", err)
+	}
+	if _, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+	//a local build with change
+	opts.Strategy = gobcy.LargestFirst
+	opts.MinConfirmations = 0
+	if refs, err = bc.GetUnspent([]string{a.Address, b.Address}, 0); err != nil {
+		t.Fatal("GetUnspent error encountered: ", err)
+	}
+	if sel, err = gobcy.SelectCoins(refs, *big.NewInt(11e4), opts); err != nil {
+		t.Fatal("SelectCoins error encountered: ", err)
+	}
+	raw, err := bc.NewRawTX()
+	if err != nil {
+		t.Fatal("NewRawTX error encountered: ", err)
+	}
+	for _, ref := range sel.Coins {
+		if err = raw.AddInput(ref); err != nil {
+			t.Fatal("AddInput error encountered: ", err)
+		}
+	}
+	if err = raw.AddOutput(dest.Address, *big.NewInt(11e4)); err != nil {
+		t.Fatal("AddOutput error encountered: ", err)
+	}
+	if err = raw.AddOutput(a.Address, sel.Change); err != nil {
+		t.Fatal("AddOutput error encountered: ", err)
+	}
+	for i, ref := range sel.Coins {
+		priv := a.Private
+		if ref.Address == b.Address {
+			priv = b.Private
+		}
+		if err = raw.Sign(i, priv); err != nil {
+			t.Fatal("Sign error encountered: ", err)
+		}
+	}
+	if fee := raw.Fee(); fee.Cmp(&sel.Fee) != 0 {
+		t.Errorf("RawTX has fee %v, expected %v", &fee, &sel.Fee)
+	}
+	if _, err = bc.PushTX(raw.Hex()); err != nil {
+		t.Fatal("PushTX error encountered: ", err)
+	}
+}
@@ -0,0 +1,306 @@
+package gobcy
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+)
+
+//SigStatus reports the signatures of one input of a
+//TXSkel being signed by several cosigners; see Status.
+type SigStatus struct {
+	Input   int      `json:"input"`
+	Have    int      `json:"have"`
+	Need    int      `json:"need"`
+	PubKeys []string `json:"pubkeys"`
+}
+
+//Export encodes a TXSkel, with the signatures added so far,
+//as a portable JSON file for cosigners on other machines to
+//import with ImportTXSkel. Multisig spends are signed by
+//several cosigners like so:
+//	skel, err := bc.NewTX(temp, true)   //on one machine
+//	file, err := skel.Export()
+//	skel, err := gobcy.ImportTXSkel(file) //on each cosigner's
+//	added, err := skel.SignPartial(signer)
+//	file, err := skel.Export()
+//	err = skel.Merge(other)               //back on the first
+//	err = skel.Finalize()
+//	skel, err = bc.SendTX(skel)
+func (skel *TXSkel) Export() (data []byte, err error) {
+	if err = skel.checkSlots(); err != nil {
+		return
+	}
+	return json.MarshalIndent(skel, "", "  ")
+}
+
+//ImportTXSkel decodes a TXSkel made by Export.
+func ImportTXSkel(data []byte) (skel TXSkel, err error) {
+	if err = json.Unmarshal(data, &skel); err != nil {
+		return
+	}
+	err = skel.checkSlots()
+	return
+}
+
+//checkSlots checks that a TXSkel's Signatures and PubKeys,
+//if any, are parallel to ToSign, which is parallel to the
+//signatures its inputs need.
+func (skel *TXSkel) checkSlots() error {
+	need := 0
+	for _, in := range skel.Trans.Inputs {
+		need += sigsNeeded(in)
+	}
+	if need != len(skel.ToSign) {
+		return errors.New("*TXSkel error: inputs don't match the length of ToSign array")
+	}
+	if len(skel.Signatures) != 0 && (len(skel.Signatures) != need || len(skel.PubKeys) != need) {
+		return errors.New("*TXSkel error: Signatures and PubKeys must be parallel to ToSign")
+	}
+	return nil
+}
+
+//starts returns the first index in ToSign of each input.
+func (skel *TXSkel) starts() (starts []int) {
+	start := 0
+	for _, in := range skel.Trans.Inputs {
+		starts = append(starts, start)
+		start += sigsNeeded(in)
+	}
+	return
+}
+
+//slots returns the first index in ToSign of each input,
+//and makes room for its signatures and their pubkeys.
+func (skel *TXSkel) slots() (starts []int, err error) {
+	if err = skel.checkSlots(); err != nil {
+		return
+	}
+	if len(skel.Signatures) == 0 {
+		skel.Signatures = make([]string, len(skel.ToSign))
+		skel.PubKeys = make([]string, len(skel.ToSign))
+	}
+	return skel.starts(), nil
+}
+
+//addSig puts sig by pub in the first free slot of input i,
+//unless that input is already fully signed or signed by pub.
+func (skel *TXSkel) addSig(starts []int, i int, sig, pub string) bool {
+	need := sigsNeeded(skel.Trans.Inputs[i])
+	free := -1
+	for j := starts[i]; j < starts[i]+need; j++ {
+		if skel.Signatures[j] != "" && skel.PubKeys[j] == pub {
+			return false
+		}
+		if skel.Signatures[j] == "" && free < 0 {
+			free = j
+		}
+	}
+	if free < 0 {
+		return false
+	}
+	skel.Signatures[free], skel.PubKeys[free] = sig, pub
+	return true
+}
+
+//SignPartial adds the signatures signer can make to a TXSkel,
+//leaving the others to other cosigners, and returns how many
+//it added. Unlike SignWith, it's not an error for signer to
+//have no key for some inputs, but the Signatures and PubKeys
+//then have empty entries until Finalize. Like SignWith, it
+//doesn't check what it signs; call Verify first.
+func (skel *TXSkel) SignPartial(signer Signer) (added int, err error) {
+	starts, err := skel.slots()
+	if err != nil {
+		return
+	}
+	pubs, err := signer.PubKeys()
+	if err != nil {
+		return
+	}
+	for i, in := range skel.Trans.Inputs {
+		for _, pub := range inputKeys(in, pubs) {
+			tosign, err := hex.DecodeString(skel.ToSign[starts[i]])
+			if err != nil {
+				return added, err
+			}
+			sig, err := signer.SignHash(pub, tosign)
+			if err != nil {
+				return added, err
+			}
+			if skel.addSig(starts, i, hex.EncodeToString(sig), hex.EncodeToString(pub)) {
+				added++
+			}
+		}
+	}
+	return
+}
+
+//inputKeys returns the keys of pubs that can sign for in:
+//those among its pubkeys if it's multisig, or otherwise
+//the key of its address.
+func inputKeys(in TXInput, pubs [][]byte) [][]byte {
+	if strings.HasPrefix(in.ScriptType, "multisig-") {
+		return multisigKeys(in.Addresses, pubs, len(in.Addresses))
+	}
+	if key := addrKey(in.Addresses, pubs); key != nil {
+		return [][]byte{key}
+	}
+	return nil
+}
+
+//Merge adds the signatures of other, the same TXSkel signed
+//by another cosigner, to this one. Each signature is checked
+//against its pubkey, which must be one of its input's keys,
+//and its ToSign entry first, and Merge fails without changing
+//the TXSkel if any is invalid.
+func (skel *TXSkel) Merge(other TXSkel) (err error) {
+	if len(other.ToSign) != len(skel.ToSign) {
+		return errors.New("*TXSkel.Merge error: not the same TX")
+	}
+	for i := range skel.ToSign {
+		if other.ToSign[i] != skel.ToSign[i] {
+			return errors.New("*TXSkel.Merge error: not the same TX")
+		}
+	}
+	if err = other.checkSlots(); err != nil {
+		return
+	}
+	if err = skel.checkSlots(); err != nil || len(other.Signatures) == 0 {
+		return
+	}
+	starts := skel.starts()
+	for i, in := range skel.Trans.Inputs {
+		for j := starts[i]; j < starts[i]+sigsNeeded(in); j++ {
+			if other.Signatures[j] == "" {
+				continue
+			}
+			pub, err := hex.DecodeString(other.PubKeys[j])
+			if err != nil || len(inputKeys(in, [][]byte{pub})) == 0 {
+				return errors.New("*TXSkel.Merge error: signature " + strconv.Itoa(j) + ": not by a key of input " + strconv.Itoa(i))
+			}
+			if err = checkSig(other.Signatures[j], other.PubKeys[j], skel.ToSign[j]); err != nil {
+				return errors.New("*TXSkel.Merge error: signature " + strconv.Itoa(j) + ": " + err.Error())
+			}
+		}
+	}
+	if starts, err = skel.slots(); err != nil {
+		return
+	}
+	for i := range skel.Trans.Inputs {
+		for j := starts[i]; j < starts[i]+sigsNeeded(skel.Trans.Inputs[i]); j++ {
+			if other.Signatures[j] != "" {
+				skel.addSig(starts, i, other.Signatures[j], other.PubKeys[j])
+			}
+		}
+	}
+	return
+}
+
+//checkSig checks that sig is a valid signature
+//of tosign by pub, all hex-encoded.
+func checkSig(sig, pub, tosign string) error {
+	sigDat, err := hex.DecodeString(sig)
+	if err != nil {
+		return err
+	}
+	pubDat, err := hex.DecodeString(pub)
+	if err != nil {
+		return err
+	}
+	hash, err := hex.DecodeString(tosign)
+	if err != nil {
+		return err
+	}
+	parsedSig, err := ecdsa.ParseDERSignature(sigDat)
+	if err != nil {
+		return err
+	}
+	parsedPub, err := btcec.ParsePubKey(pubDat)
+	if err != nil {
+		return err
+	}
+	if !parsedSig.Verify(hash, parsedPub) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+//Status returns how many of the signatures they need
+//each input of a TXSkel has so far, and by which pubkeys.
+func (skel *TXSkel) Status() (status []SigStatus) {
+	signed := skel.checkSlots() == nil && len(skel.Signatures) != 0
+	starts := skel.starts()
+	for i, in := range skel.Trans.Inputs {
+		s := SigStatus{Input: i, Need: sigsNeeded(in)}
+		for j := starts[i]; signed && j < starts[i]+s.Need; j++ {
+			if skel.Signatures[j] != "" {
+				s.Have++
+				s.PubKeys = append(s.PubKeys, skel.PubKeys[j])
+			}
+		}
+		status = append(status, s)
+	}
+	return
+}
+
+//Complete returns whether every input of a
+//TXSkel has all the signatures it needs.
+func (skel *TXSkel) Complete() bool {
+	for _, s := range skel.Status() {
+		if s.Have < s.Need {
+			return false
+		}
+	}
+	return true
+}
+
+//Finalize readies a TXSkel signed by several cosigners for
+//SendTX, ordering the signatures of each multisig input as
+//its pubkeys are. It fails, naming them, if any inputs
+//still lack signatures.
+func (skel *TXSkel) Finalize() (err error) {
+	var missing []string
+	for _, s := range skel.Status() {
+		if s.Have < s.Need {
+			missing = append(missing, strconv.Itoa(s.Input)+" ("+strconv.Itoa(s.Have)+" of "+strconv.Itoa(s.Need)+")")
+		}
+	}
+	if len(missing) > 0 {
+		return errors.New("*TXSkel.Finalize error: missing signatures for inputs " + strings.Join(missing, ", "))
+	}
+	starts, err := skel.slots()
+	if err != nil {
+		return
+	}
+	for i, in := range skel.Trans.Inputs {
+		if !strings.HasPrefix(in.ScriptType, "multisig-") {
+			continue
+		}
+		need := sigsNeeded(in)
+		sigs := make(map[string]string, need)
+		for j := starts[i]; j < starts[i]+need; j++ {
+			sigs[skel.PubKeys[j]] = skel.Signatures[j]
+		}
+		j := starts[i]
+		for _, k := range in.Addresses {
+			pub, _ := hex.DecodeString(k)
+			for p, sig := range sigs {
+				if pubDat, _ := hex.DecodeString(p); bytes.Equal(pub, pubDat) {
+					skel.Signatures[j], skel.PubKeys[j] = sig, p
+					j++
+				}
+			}
+		}
+		if j != starts[i]+need {
+			return errors.New("*TXSkel.Finalize error: input " + strconv.Itoa(i) + " has signatures by keys it doesn't use")
+		}
+	}
+	return
+}
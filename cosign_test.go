@@ -0,0 +1,126 @@
+package gobcy_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+func TestCosign(t *testing.T) {
+	srv := gobcytest.NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	fe, err := bc.GetFeeEstimator()
+	if err != nil {
+		t.Fatal("GetFeeEstimator error encountered: ", err)
+	}
+	params, _ := gobcy.ChainParams("bcy", "test")
+	var ms []gobcy.AddrKeychain
+	var pubkeys []string
+	for i := 0; i < 3; i++ {
+		k, err := bc.GenAddrKeychain()
+		if err != nil {
+			t.Fatal("GenAddrKeychain error encountered: ", err)
+		}
+		ms = append(ms, k)
+		pubkeys = append(pubkeys, k.Public)
+	}
+	srv.Fund(multisigAddr(t, pubkeys, 2, params), 1e5)
+	srv.Mine()
+	dest, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	spend, _ := gobcy.TempMultiTX("", dest.Address, *big.NewInt(5e4), 2, pubkeys)
+	skel, err := bc.NewTX(spend, true)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	file, err := skel.Export()
+	if err != nil {
+		t.Fatal("Export error encountered: ", err)
+	}
+	//each cosigner signs their own copy
+	cosign := func(k gobcy.AddrKeychain) gobcy.TXSkel {
+		mine, err := gobcy.ImportTXSkel(file)
+		if err != nil {
+			t.Fatal("ImportTXSkel error encountered: ", err)
+		}
+		if err = mine.Verify(spend, 2*fe.High, params); err != nil {
+			t.Fatal("Verify error encountered: ", err)
+		}
+		signer := gobcy.NewKeySigner()
+		signer.AddWIF(k.Wif)
+		added, err := mine.SignPartial(signer)
+		if err != nil || added != 1 {
+			t.Fatalf("SignPartial added %d signatures, error %v", added, err)
+		}
+		signed, err := mine.Export()
+		if err != nil {
+			t.Fatal("Export error encountered: ", err)
+		}
+		if mine, err = gobcy.ImportTXSkel(signed); err != nil {
+			t.Fatal("ImportTXSkel error encountered: ", err)
+		}
+		return mine
+	}
+	last, first := cosign(ms[2]), cosign(ms[0])
+	if err = skel.Merge(last); err != nil {
+		t.Fatal("Merge error encountered: ", err)
+	}
+	status := skel.Status()
+	if len(status) != 1 || status[0].Have != 1 || status[0].Need != 2 || status[0].PubKeys[0] != ms[2].Public {
+		t.Errorf("Status returned %+v, expected 1 of 2 by %s", status, ms[2].Public)
+	}
+	if skel.Complete() || skel.Finalize() == nil {
+		t.Error("Expected an incomplete TXSkel not to finalize")
+	}
+	forged := first
+	forged.Signatures = append([]string(nil), first.Signatures...)
+	forged.Signatures[0], forged.Signatures[1] = last.Signatures[0], ""
+	forged.PubKeys = append([]string(nil), first.PubKeys...)
+	if err = skel.Merge(forged); err == nil {
+		t.Error("Expected error merging a signature by the wrong key")
+	}
+	//valid signatures by keys the input doesn't use
+	outsider := first
+	outsider.Signatures = make([]string, len(first.ToSign))
+	outsider.PubKeys = make([]string, len(first.ToSign))
+	for j := range outsider.ToSign {
+		priv, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer := gobcy.NewKeySigner()
+		signer.Add(priv, true)
+		pubs, _ := signer.PubKeys()
+		tosign, _ := hex.DecodeString(outsider.ToSign[j])
+		sig, err := signer.SignHash(pubs[0], tosign)
+		if err != nil {
+			t.Fatal("SignHash error encountered: ", err)
+		}
+		outsider.Signatures[j], outsider.PubKeys[j] = hex.EncodeToString(sig), hex.EncodeToString(pubs[0])
+	}
+	if err = skel.Merge(outsider); err == nil {
+		t.Error("Expected error merging signatures by keys of no cosigner")
+	}
+	if err = skel.Merge(first); err != nil {
+		t.Fatal("Merge error encountered: ", err)
+	}
+	if !skel.Complete() {
+		t.Fatalf("Expected a complete TXSkel, Status returned %+v", skel.Status())
+	}
+	if err = skel.Finalize(); err != nil {
+		t.Fatal("Finalize error encountered: ", err)
+	}
+	if skel.PubKeys[0] != ms[0].Public || skel.PubKeys[1] != ms[2].Public {
+		t.Error("Finalize didn't order the signatures as the pubkeys")
+	}
+	if _, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+}
@@ -0,0 +1,100 @@
+package gobcy
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//APIError represents an error returned by the BlockCypher API,
+//either as a non-successful HTTP status or as an "errors" array
+//within an otherwise successful response (like a TXSkel from NewTX).
+//Use errors.As to retrieve it from an API method's error:
+//	var apiErr *gobcy.APIError
+//	if errors.As(err, &apiErr) && apiErr.StatusCode == 400 {
+//		fmt.Println(apiErr.Messages)
+//	}
+type APIError struct {
+	//StatusCode is the HTTP status code of the response.
+	StatusCode int
+	//Messages holds the individual error messages
+	//returned by BlockCypher, if any.
+	Messages []string
+	//Body is the raw response body.
+	Body []byte
+	//Path is the URL path of the request, without
+	//its query parameters.
+	Path string
+	//RetryAfter is the delay requested by the Retry-After
+	//header, or zero if none was sent.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	status := "HTTP " + strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode)
+	if len(e.Messages) == 0 {
+		return status
+	}
+	return status + ", Message(s): " + strings.Join(e.Messages, ", ")
+}
+
+//IsNotFound reports whether err is an *APIError
+//with an HTTP 404 status.
+func IsNotFound(err error) bool {
+	return hasStatus(err, http.StatusNotFound)
+}
+
+//IsRateLimited reports whether err is an *APIError
+//with an HTTP 429 status, meaning your token's limits
+//were exceeded.
+func IsRateLimited(err error) bool {
+	return hasStatus(err, http.StatusTooManyRequests)
+}
+
+//IsUnauthorized reports whether err is an *APIError
+//with an HTTP 401 or 403 status, usually from a missing
+//or invalid token.
+func IsUnauthorized(err error) bool {
+	return hasStatus(err, http.StatusUnauthorized) || hasStatus(err, http.StatusForbidden)
+}
+
+func hasStatus(err error, code int) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == code
+}
+
+//errorLister is implemented by response types
+//that carry their own list of errors.
+type errorLister interface {
+	apiErrors() []string
+}
+
+//newAPIError fills an *APIError from the
+//response's status, headers and body.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: body}
+	if resp.Request != nil && resp.Request.URL != nil {
+		apiErr.Path = resp.Request.URL.Path
+	}
+	apiErr.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+	return apiErr
+}
+
+//parseRetryAfter parses a Retry-After header given
+//either in seconds or as an HTTP date.
+func parseRetryAfter(h string) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(h); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,249 @@
+package gobcy
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+//FeeEstimator turns the fee tiers of a Blockchain into fee
+//rates in satoshis per virtual byte, and estimates the fees
+//of planned transactions with them. Get one for the current
+//state of the Coin/Chain with GetFeeEstimator.
+type FeeEstimator struct {
+	High   float64
+	Medium float64
+	Low    float64
+}
+
+//NewFeeEstimator returns the FeeEstimator of chain's
+//HighFee, MediumFee and LowFee, which are per kilobyte.
+func NewFeeEstimator(chain Blockchain) FeeEstimator {
+	return FeeEstimator{
+		High:   float64(chain.HighFee) / 1000,
+		Medium: float64(chain.MediumFee) / 1000,
+		Low:    float64(chain.LowFee) / 1000,
+	}
+}
+
+//GetFeeEstimator returns the FeeEstimator of the
+//current fee tiers of the configured Coin/Chain.
+func (api *API) GetFeeEstimator() (fe FeeEstimator, err error) {
+	return api.GetFeeEstimatorCtx(context.Background())
+}
+
+//GetFeeEstimatorCtx is like GetFeeEstimator, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetFeeEstimatorCtx(ctx context.Context) (fe FeeEstimator, err error) {
+	chain, err := api.GetChainCtx(ctx)
+	if err != nil {
+		return
+	}
+	fe = NewFeeEstimator(chain)
+	return
+}
+
+//Rate returns the fee rate, in satoshis per virtual byte, of
+//a TX preference: "high", "medium", "low" or "zero".
+func (fe FeeEstimator) Rate(preference string) (rate float64, err error) {
+	switch preference {
+	case "high":
+		rate = fe.High
+	case "medium":
+		rate = fe.Medium
+	case "low":
+		rate = fe.Low
+	case "zero":
+	default:
+		err = errors.New("FeeEstimator.Rate error: unknown preference " + preference)
+	}
+	return
+}
+
+//RateForTarget returns the fee rate, in satoshis per virtual
+//byte, to confirm within about blocks blocks, following
+//BlockCypher's tiers: high for 1-2 blocks, medium for 3-6,
+//and low beyond.
+func (fe FeeEstimator) RateForTarget(blocks int) float64 {
+	switch {
+	case blocks <= 2:
+		return fe.High
+	case blocks <= 6:
+		return fe.Medium
+	}
+	return fe.Low
+}
+
+//Fee returns the fee of a planned TX at the rate of preference;
+//see Rate. The TX spends inputs and pays outputs of the given
+//script types, as accepted by EstimateVSize. Set it as the
+//Fees of a TX passed to NewTX, or use it for a local build.
+func (fe FeeEstimator) Fee(preference string, inputs, outputs []string) (fee big.Int, err error) {
+	rate, err := fe.Rate(preference)
+	if err != nil {
+		return
+	}
+	vsize, err := EstimateVSize(inputs, outputs)
+	if err != nil {
+		return
+	}
+	fee = FeeFor(rate, vsize)
+	return
+}
+
+//FeeFor returns the fee of vsize virtual bytes at rate
+//satoshis per virtual byte, rounded up.
+func FeeFor(rate float64, vsize int) (fee big.Int) {
+	fee.SetInt64(int64(math.Ceil(rate * float64(vsize))))
+	return
+}
+
+//EstimateVSize estimates the virtual size of a TX once signed,
+//which spends inputs and pays outputs of the given script
+//types. Inputs can be "pay-to-pubkey-hash", "pay-to-witness-
+//pubkey-hash", "pay-to-script-hash" (meaning P2SH-P2WPKH) or
+//"multisig-n-of-m" (P2SH multisig); outputs can be those or
+//"pay-to-witness-script-hash" and "null-data". Signatures are
+//assumed to take their largest size, so the estimate can be
+//up to two bytes too large per signature.
+func EstimateVSize(inputs, outputs []string) (vsize int, err error) {
+	var sizes []int
+	for _, out := range outputs {
+		size, err := outputSize(out)
+		if err != nil {
+			return 0, err
+		}
+		sizes = append(sizes, size)
+	}
+	weight, err := txWeight(inputs, sizes)
+	return (weight + 3) / 4, err
+}
+
+//sigSize is the largest size of a DER signature,
+//with its sighash type.
+const sigSize = 73
+
+//txWeight estimates the weight of a TX spending inputs of the
+//given script types and paying outputs of the given sizes.
+func txWeight(inputs []string, outputs []int) (weight int, err error) {
+	base := 8 + wire.VarIntSerializeSize(uint64(len(inputs))) + wire.VarIntSerializeSize(uint64(len(outputs)))
+	witness := 0
+	for _, size := range outputs {
+		base += size
+	}
+	for _, in := range inputs {
+		size, wit, err := inputSize(in)
+		if err != nil {
+			return 0, err
+		}
+		base += size
+		witness += wit
+	}
+	weight = base * 4
+	if witness > 0 {
+		//the segwit marker and flag, and an empty
+		//witness for each non-segwit input
+		weight += 2 + witness
+		for _, in := range inputs {
+			if in == "pay-to-pubkey-hash" || strings.HasPrefix(in, "multisig-") {
+				weight++
+			}
+		}
+	}
+	return
+}
+
+//inputSize returns the size of an input of a script type,
+//and the size of its witness if any.
+func inputSize(scriptType string) (size, wit int, err error) {
+	script := 0
+	switch {
+	case scriptType == "pay-to-pubkey-hash":
+		script = 1 + sigSize + 1 + 33
+	case scriptType == "pay-to-witness-pubkey-hash":
+		wit = 1 + 1 + sigSize + 1 + 33
+	case scriptType == "pay-to-script-hash":
+		script, wit = 1+22, 1+1+sigSize+1+33
+	case strings.HasPrefix(scriptType, "multisig-"):
+		var n, m int
+		if parts := strings.Split(scriptType, "-"); len(parts) == 4 {
+			n, _ = strconv.Atoi(parts[1])
+			m, _ = strconv.Atoi(parts[3])
+		}
+		if n == 0 || m < n {
+			err = errors.New("Func EstimateVSize: invalid script type " + scriptType)
+			return
+		}
+		redeem := 3 + 34*m
+		script = 1 + n*(1+sigSize) + pushSize(redeem) + redeem
+	default:
+		err = errors.New("Func EstimateVSize: unsupported input script type " + scriptType)
+		return
+	}
+	size = 36 + wire.VarIntSerializeSize(uint64(script)) + script + 4
+	return
+}
+
+//pushSize returns the size of the opcode pushing n bytes.
+func pushSize(n int) int {
+	switch {
+	case n < txscript.OP_PUSHDATA1:
+		return 1
+	case n <= 0xff:
+		return 2
+	}
+	return 3
+}
+
+//outputSize returns the size of an output of a script type.
+func outputSize(scriptType string) (size int, err error) {
+	script := 0
+	switch {
+	case scriptType == "pay-to-pubkey-hash":
+		script = 25
+	case scriptType == "pay-to-script-hash", strings.HasPrefix(scriptType, "multisig-"):
+		script = 23
+	case scriptType == "pay-to-witness-pubkey-hash":
+		script = 22
+	case scriptType == "pay-to-witness-script-hash":
+		script = 34
+	case scriptType == "null-data":
+		script = 2 + 80
+	default:
+		err = errors.New("Func EstimateVSize: unsupported output script type " + scriptType)
+		return
+	}
+	size = 8 + wire.VarIntSerializeSize(uint64(script)) + script
+	return
+}
+
+//VSize estimates the virtual size of a RawTX once all
+//its inputs are signed, like EstimateVSize. Multiply
+//it by a fee rate with FeeFor to get its fee.
+func (raw *RawTX) VSize() int {
+	var inputs []string
+	for _, prev := range raw.prevs {
+		switch {
+		case prev.redeem != nil:
+			inputs = append(inputs, "multisig-"+strconv.Itoa(prev.n)+"-of-"+strconv.Itoa(len(prev.pubkeys)))
+		case txscript.IsPayToWitnessPubKeyHash(prev.script):
+			inputs = append(inputs, "pay-to-witness-pubkey-hash")
+		case txscript.IsPayToScriptHash(prev.script):
+			inputs = append(inputs, "pay-to-script-hash")
+		default:
+			inputs = append(inputs, "pay-to-pubkey-hash")
+		}
+	}
+	var outputs []int
+	for _, out := range raw.msg.TxOut {
+		outputs = append(outputs, out.SerializeSize())
+	}
+	weight, _ := txWeight(inputs, outputs)
+	return (weight + 3) / 4
+}
@@ -0,0 +1,136 @@
+package gobcy_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+func TestFeeEstimator(t *testing.T) {
+	srv := gobcytest.NewServer("btc", "test3")
+	defer srv.Close()
+	bc := srv.API("token")
+	params := &chaincfg.TestNet3Params
+	fe, err := bc.GetFeeEstimator()
+	if err != nil {
+		t.Fatal("GetFeeEstimator error encountered: ", err)
+	}
+	if fe.High != 40 || fe.Medium != 20 || fe.Low != 10 {
+		t.Errorf("GetFeeEstimator returned %+v, expected 40/20/10 sat/vbyte", fe)
+	}
+	if rate := fe.RateForTarget(4); rate != fe.Medium {
+		t.Errorf("RateForTarget(4) returned %v, expected %v", rate, fe.Medium)
+	}
+	if _, err = fe.Rate("urgent"); err == nil {
+		t.Error("Expected error for an unknown preference")
+	}
+	if _, err = gobcy.EstimateVSize([]string{"multisig-3-of-2"}, nil); err == nil {
+		t.Error("Expected error estimating an invalid script type")
+	}
+	vsize, err := gobcy.EstimateVSize([]string{"pay-to-pubkey-hash"}, []string{"pay-to-pubkey-hash", "pay-to-pubkey-hash"})
+	if err != nil || vsize != 227 {
+		t.Errorf("EstimateVSize returned %d, error %v, expected 227", vsize, err)
+	}
+	//compare the estimate of a RawTX to its signed size
+	var privs []*btcec.PrivateKey
+	var pubs []string
+	for i := 0; i < 3; i++ {
+		priv, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs = append(privs, priv)
+		pubs = append(pubs, hex.EncodeToString(priv.PubKey().SerializeCompressed()))
+	}
+	keyHash := btcutil.Hash160(privs[0].PubKey().SerializeCompressed())
+	program, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(keyHash).Script()
+	var msAddrs []*btcutil.AddressPubKey
+	for _, priv := range privs {
+		addr, _ := btcutil.NewAddressPubKey(priv.PubKey().SerializeCompressed(), params)
+		msAddrs = append(msAddrs, addr)
+	}
+	redeem, _ := txscript.MultiSigScript(msAddrs, 2)
+	p2pkh, _ := btcutil.NewAddressPubKeyHash(keyHash, params)
+	p2wpkh, _ := btcutil.NewAddressWitnessPubKeyHash(keyHash, params)
+	nested, _ := btcutil.NewAddressScriptHash(program, params)
+	multisig, _ := btcutil.NewAddressScriptHash(redeem, params)
+	from := []string{p2pkh.String(), p2wpkh.String(), nested.String(), multisig.String()}
+	for _, addr := range from {
+		srv.Fund(addr, 1e5)
+	}
+	srv.Mine()
+	raw, err := bc.NewRawTX()
+	if err != nil {
+		t.Fatal("NewRawTX error encountered: ", err)
+	}
+	for _, addr := range from {
+		info, err := bc.GetAddr(addr, gobcy.AddrQuery{UnspentOnly: true, IncludeScript: true}.Params())
+		if err != nil || len(info.TXRefs) != 1 {
+			t.Fatalf("GetAddr of %s returned %d refs, error %v", addr, len(info.TXRefs), err)
+		}
+		if addr == multisig.String() {
+			err = raw.AddMultisigInput(info.TXRefs[0], 2, pubs)
+		} else {
+			err = raw.AddInput(info.TXRefs[0])
+		}
+		if err != nil {
+			t.Fatal("AddInput error encountered: ", err)
+		}
+	}
+	estimate, err := gobcy.EstimateVSize([]string{"pay-to-pubkey-hash", "pay-to-witness-pubkey-hash", "pay-to-script-hash", "multisig-2-of-3"},
+		[]string{"pay-to-witness-pubkey-hash"})
+	if err != nil {
+		t.Fatal("EstimateVSize error encountered: ", err)
+	}
+	fee := gobcy.FeeFor(fe.Low, estimate)
+	if err = raw.AddOutput(p2wpkh.String(), *big.NewInt(4e5 - fee.Int64())); err != nil {
+		t.Fatal("AddOutput error encountered: ", err)
+	}
+	if raw.VSize() != estimate {
+		t.Errorf("VSize returned %d, expected %d as EstimateVSize", raw.VSize(), estimate)
+	}
+	privHex := func(i int) string {
+		return hex.EncodeToString(privs[i].Serialize())
+	}
+	for i := 0; i < 3; i++ {
+		if err = raw.Sign(i, privHex(0)); err != nil {
+			t.Fatal("Sign error encountered: ", err)
+		}
+	}
+	if err = raw.Sign(3, privHex(0), privHex(2)); err != nil {
+		t.Fatal("Sign error encountered: ", err)
+	}
+	skel, err := bc.PushTX(raw.Hex())
+	if err != nil {
+		t.Fatal("PushTX error encountered: ", err)
+	}
+	//up to two bytes too large per signature
+	if actual := skel.Trans.VirtualSize; actual > estimate || actual < estimate-10 {
+		t.Errorf("EstimateVSize returned %d, but the signed TX is %d vbytes", estimate, actual)
+	}
+	//a fee for NewTX
+	keys := fundedKeys(t, srv, bc, 3e5)
+	fee, err = fe.Fee("low", []string{"pay-to-pubkey-hash"}, []string{"pay-to-pubkey-hash", "pay-to-pubkey-hash"})
+	if err != nil {
+		t.Fatal("Fee error encountered: ", err)
+	}
+	if fee.Int64() != 2270 {
+		t.Errorf("Fee returned %v, expected 2270", &fee)
+	}
+	req := gobcy.TempNewTX(keys.Address, p2pkh.String(), *big.NewInt(1e5))
+	req.Fees = fee
+	sent, err := bc.NewTX(req, false)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if sent.Trans.Fees.Cmp(&fee) != 0 {
+		t.Errorf("NewTX returned fees %v, expected %v", &sent.Trans.Fees, &fee)
+	}
+}
@@ -0,0 +1,51 @@
+package gobcy
+
+import (
+	"context"
+	"time"
+)
+
+//Checkpoint identifies the last block a consumer of
+//Follow processed, so it can resume after a restart.
+type Checkpoint struct {
+	Height int    `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+//CheckpointOf returns the Checkpoint of block.
+func CheckpointOf(block Block) Checkpoint {
+	return Checkpoint{Height: block.Height, Hash: block.Hash}
+}
+
+//FollowFunc receives each Block found by Follow, in order. If
+//reorg isn't nil, the blocks above reorg.ForkHeight it received
+//before are no longer in the chain, and should be rolled back
+//before processing block. Returning an error stops Follow.
+type FollowFunc func(block Block, reorg *Reorg) error
+
+//Follow polls GetChain every interval, for environments that can't
+//receive WebHooks, and calls fn with every block above from, including
+//any it missed between polls. from.Hash, if set, is checked against
+//the chain like any other block, so a fork below it is reported too.
+//To start at the current tip, use the Height and Hash from GetChain.
+//The params (see BlockQuery) are used to get every Block.
+//Follow runs until ctx is done or an error occurs, including one
+//returned by fn, and returns that error. Since fn sees every block in
+//order, storing CheckpointOf(block) as it goes lets a restarted Follow
+//pick up without missing or repeating any.
+func (api *API) Follow(ctx context.Context, from Checkpoint, interval time.Duration, params map[string]string, fn FollowFunc) error {
+	sc := api.ScanBlocksCtx(ctx, from.Height+1, -1, from.Hash, params)
+	for {
+		for sc.Next() {
+			if err := fn(sc.Block(), sc.Reorg()); err != nil {
+				return err
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return err
+		}
+		if err := sleepCtx(ctx, interval); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package gobcy_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+)
+
+func TestFollow(t *testing.T) {
+	srv := gobcytest.NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	for i := 0; i < 3; i++ {
+		srv.Mine()
+	}
+	start, err := bc.GetBlock(1, "", nil)
+	if err != nil {
+		t.Fatal("GetBlock error encountered: ", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	blocks := make(chan gobcy.Block)
+	done := make(chan error, 1)
+	go func() {
+		done <- bc.Follow(ctx, gobcy.CheckpointOf(start), 10*time.Millisecond, nil, func(b gobcy.Block, r *gobcy.Reorg) error {
+			if r != nil {
+				return fmt.Errorf("unexpected reorg %+v", r)
+			}
+			blocks <- b
+			return nil
+		})
+	}()
+	next := func() gobcy.Block {
+		select {
+		case b := <-blocks:
+			return b
+		case err := <-done:
+			t.Fatal("Follow error encountered: ", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("Follow timed out")
+		}
+		return gobcy.Block{}
+	}
+	for want := 2; want <= 3; want++ {
+		if b := next(); b.Height != want {
+			t.Fatalf("Follow returned block %d, expected %d", b.Height, want)
+		}
+	}
+	//blocks mined between polls are all delivered
+	srv.Mine()
+	srv.Mine()
+	for want := 4; want <= 5; want++ {
+		if b := next(); b.Height != want {
+			t.Fatalf("Follow returned block %d, expected %d", b.Height, want)
+		}
+	}
+	cancel()
+	if err = <-done; !errors.Is(err, context.Canceled) {
+		t.Error("Expected context.Canceled from Follow, got: ", err)
+	}
+	//errors from fn stop Follow
+	stop := errors.New("stop")
+	err = bc.Follow(context.Background(), gobcy.Checkpoint{Height: -1}, time.Millisecond, nil, func(gobcy.Block, *gobcy.Reorg) error {
+		return stop
+	})
+	if err != stop {
+		t.Error("Expected Follow to return the error from fn, got: ", err)
+	}
+}
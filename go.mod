@@ -1,7 +1,19 @@
 module github.com/blockcypher/gobcy/v2
 
-go 1.18
+go 1.26.0
 
-require github.com/btcsuite/btcd/btcec/v2 v2.3.2
+require (
+	github.com/btcsuite/btcd v0.24.2
+	github.com/btcsuite/btcd/btcec/v2 v2.3.2
+	github.com/btcsuite/btcd/btcutil v1.1.6
+	github.com/btcsuite/btcd/btcutil/psbt v1.1.8
+	github.com/btcsuite/btcd/chaincfg/chainhash v1.1.0
+	golang.org/x/crypto v0.57.0
+)
 
-require github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0 // indirect
+require (
+	github.com/btcsuite/btclog v0.0.0-20170628155309-84c8d2346e9f // indirect
+	github.com/decred/dcrd/crypto/blake256 v1.0.1 // indirect
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0 // indirect
+	golang.org/x/sys v0.48.0 // indirect
+)
@@ -12,12 +12,13 @@ package gobcy
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
-	"errors"
 	"io"
 	"net/http"
 	"net/url"
-	"strconv"
+	"strings"
+	"time"
 )
 
 const baseURL = "https://api.blockcypher.com/v1/"
@@ -29,97 +30,243 @@ const baseURL = "https://api.blockcypher.com/v1/"
 //All your credentials are stored within an API struct, as are
 //many of the API methods.
 //You can allocate an API struct like so:
-//	bc = gobcy.API{"your-api-token","btc","main"}
+//	bc = gobcy.API{Token: "your-api-token", Coin: "btc", Chain: "main"}
 //Then query as you like:
 //	chain = bc.GetChain()
+//An API allocated this way uses http.DefaultClient and the public
+//BlockCypher endpoint; use NewAPI to configure either.
 type API struct {
 	Token, Coin, Chain string
+	conf               *config
 }
 
-//getResponse is a boilerplate for HTTP GET responses.
-func getResponse(target *url.URL, decTarget interface{}) (err error) {
-	resp, err := http.Get(target.String())
-	if err != nil {
-		return
+//config holds the optional client settings applied by NewAPI.
+type config struct {
+	client    *http.Client
+	baseURL   string
+	userAgent string
+	params    map[string]string
+	retry     RetryPolicy
+	limiter   *Limiter
+	mw        []Middleware
+	tokenHdr  string
+}
+
+//Option configures an API allocated with NewAPI.
+type Option func(*config)
+
+//WithHTTPClient sets the *http.Client used for every request,
+//allowing custom timeouts, proxies or TLS settings.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		err = respErrorMaker(resp.StatusCode, resp.Body)
-		return
+}
+
+//WithBaseURL sets the root URL requests are sent to, in place
+//of "https://api.blockcypher.com/v1/". Useful for pointing the
+//library at a local stand-in server.
+func WithBaseURL(base string) Option {
+	return func(c *config) {
+		if !strings.HasSuffix(base, "/") {
+			base += "/"
+		}
+		c.baseURL = base
 	}
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(decTarget)
-	return
 }
 
-//postResponse is a boilerplate for HTTP POST responses.
-func postResponse(target *url.URL, encTarget interface{}, decTarget interface{}) (err error) {
-	var data bytes.Buffer
-	enc := json.NewEncoder(&data)
-	if err = enc.Encode(encTarget); err != nil {
-		return
+//WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) Option {
+	return func(c *config) {
+		c.userAgent = ua
 	}
-	resp, err := http.Post(target.String(), "application/json", &data)
-	if err != nil {
-		return
+}
+
+//WithParams sets URL parameters added to every request. Parameters
+//passed to individual API methods take precedence over these.
+func WithParams(params map[string]string) Option {
+	return func(c *config) {
+		c.params = make(map[string]string, len(params))
+		for k, v := range params {
+			c.params[k] = v
+		}
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		err = respErrorMaker(resp.StatusCode, resp.Body)
-		return
+}
+
+//NewAPI allocates an API for the given token/coin/chain,
+//configured by the given Options:
+//	bc := gobcy.NewAPI("your-api-token", "btc", "main",
+//		gobcy.WithHTTPClient(&http.Client{Timeout: 10 * time.Second}))
+func NewAPI(token, coin, chain string, opts ...Option) *API {
+	conf := &config{}
+	for _, opt := range opts {
+		opt(conf)
 	}
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(decTarget)
-	return
+	return &API{Token: token, Coin: coin, Chain: chain, conf: conf}
 }
 
-//putResponse is a boilerplate for HTTP PUT responses.
-func putResponse(target *url.URL, encTarget interface{}) (err error) {
-	var data bytes.Buffer
-	enc := json.NewEncoder(&data)
-	if err = enc.Encode(encTarget); err != nil {
-		return
+//httpClient returns the configured *http.Client, or
+//http.DefaultClient if none was set.
+func (api *API) httpClient() *http.Client {
+	if api.conf == nil || api.conf.client == nil {
+		return http.DefaultClient
 	}
-	req, err := http.NewRequest("PUT", target.String(), &data)
-	if err != nil {
-		return
+	return api.conf.client
+}
+
+//base returns the configured base URL, or the public
+//BlockCypher endpoint if none was set.
+func (api *API) base() string {
+	if api.conf == nil || api.conf.baseURL == "" {
+		return baseURL
 	}
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return
+	return api.conf.baseURL
+}
+
+//do sends req with the configured client and headers.
+func (api *API) do(req *http.Request) (resp *http.Response, err error) {
+	if api.conf != nil && api.conf.userAgent != "" {
+		req.Header.Set("User-Agent", api.conf.userAgent)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		err = respErrorMaker(resp.StatusCode, resp.Body)
+	if req.Body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	return
+	if h := api.tokenHeader(); h != "" && api.Token != "" {
+		req.Header.Set(h, api.Token)
+	}
+	return api.httpClient().Do(req)
+}
+
+//getResponse is a boilerplate for HTTP GET responses.
+func (api *API) getResponse(ctx context.Context, target *url.URL, decTarget interface{}) (err error) {
+	return api.request(ctx, "GET", retryIdempotent, target, nil, decTarget, http.StatusOK)
+}
+
+//postResponse is a boilerplate for HTTP POST responses.
+//These are never retried; see postRetryResponse.
+func (api *API) postResponse(ctx context.Context, target *url.URL, encTarget interface{}, decTarget interface{}) (err error) {
+	return api.postRetryResponse(ctx, retryNever, target, encTarget, decTarget)
+}
+
+//postRetryResponse is a boilerplate for HTTP POST responses
+//that may be retried according to class.
+func (api *API) postRetryResponse(ctx context.Context, class retryClass, target *url.URL, encTarget interface{}, decTarget interface{}) (err error) {
+	return api.request(ctx, "POST", class, target, encTarget, decTarget, http.StatusOK, http.StatusCreated)
+}
+
+//putResponse is a boilerplate for HTTP PUT responses.
+func (api *API) putResponse(ctx context.Context, target *url.URL, encTarget interface{}) (err error) {
+	return api.request(ctx, "PUT", retryIdempotent, target, encTarget, nil, http.StatusOK, http.StatusNoContent)
 }
 
 //deleteResponse is a boilerplate for HTTP DELETE responses.
-func deleteResponse(target *url.URL) (err error) {
-	req, err := http.NewRequest("DELETE", target.String(), nil)
-	if err != nil {
+func (api *API) deleteResponse(ctx context.Context, target *url.URL) (err error) {
+	return api.request(ctx, "DELETE", retryIdempotent, target, nil, nil, http.StatusOK, http.StatusNoContent)
+}
+
+//request sends an HTTP request with encTarget as its JSON body
+//(for POST and PUT), throttled by the Limiter and retried as allowed
+//by the RetryPolicy and class, then decodes the response into decTarget.
+//Each attempt passes through the configured Middleware.
+func (api *API) request(ctx context.Context, method string, class retryClass, target *url.URL, encTarget interface{}, decTarget interface{}, codes ...int) (err error) {
+	var data []byte
+	if method == "POST" || method == "PUT" {
+		if data, err = json.Marshal(encTarget); err != nil {
+			return
+		}
+	}
+	kind := limitKindOf(method, target)
+	send := api.invoker(func(ctx context.Context, c *Call) (res CallResult) {
+		var body io.Reader
+		if data != nil {
+			body = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, c.Method, target.String(), body)
+		if err != nil {
+			res.Err = err
+			return
+		}
+		for k, v := range c.Header {
+			req.Header[k] = v
+		}
+		start := time.Now()
+		resp, err := api.do(req)
+		if err == nil {
+			res.StatusCode = resp.StatusCode
+			err = decodeResponse(resp, decTarget, codes...)
+			resp.Body.Close()
+		}
+		res.Latency = time.Since(start)
+		res.Err = api.redactErr(err)
 		return
+	})
+	for attempt := 1; ; attempt++ {
+		if l := api.limiter(); l != nil {
+			if err = l.wait(ctx, kind); err != nil {
+				return
+			}
+		}
+		call := &Call{
+			Method:   method,
+			Endpoint: api.endpointOf(target),
+			Coin:     api.Coin,
+			Chain:    api.Chain,
+			URL:      api.redact(target.String()),
+			Header:   make(http.Header),
+			Attempt:  attempt,
+		}
+		err = send(ctx, call).Err
+		wait, retry := api.retryPolicy().next(ctx, class, attempt, err)
+		if !retry {
+			return
+		}
+		if err = sleepCtx(ctx, wait); err != nil {
+			return
+		}
 	}
-	resp, err := http.DefaultClient.Do(req)
+}
+
+//decodeResponse checks resp's status against the accepted codes,
+//returning an *APIError if it doesn't match, and otherwise decodes
+//the body into decTarget (if non-nil). Response types that carry
+//their own error lists, like TXSkel, are turned into an *APIError
+//even on success.
+func decodeResponse(resp *http.Response, decTarget interface{}, codes ...int) (err error) {
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		err = respErrorMaker(resp.StatusCode, resp.Body)
+	ok := false
+	for _, c := range codes {
+		if resp.StatusCode == c {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		err = respErrorMaker(resp, body)
+		return
+	}
+	if decTarget == nil {
+		return
+	}
+	if err = json.Unmarshal(body, decTarget); err != nil {
+		return
+	}
+	if el, ok := decTarget.(errorLister); ok {
+		if msgs := el.apiErrors(); len(msgs) > 0 {
+			apiErr := newAPIError(resp, body)
+			apiErr.Messages = msgs
+			err = apiErr
+		}
 	}
 	return
 }
 
 //respErrorMaker checks error messages/if they are multiple errors
-//serializes them into a single error message
-func respErrorMaker(statusCode int, body io.Reader) (err error) {
-	status := "HTTP " + strconv.Itoa(statusCode) + " " + http.StatusText(statusCode)
-	if statusCode == 429 {
-		err = errors.New(status)
-		return
-	}
+//collects them into an *APIError
+func respErrorMaker(resp *http.Response, body []byte) (err error) {
+	apiErr := newAPIError(resp, body)
 	type errorJSON struct {
 		Err    string `json:"error"`
 		Errors []struct {
@@ -127,53 +274,59 @@ func respErrorMaker(statusCode int, body io.Reader) (err error) {
 		} `json:"errors"`
 	}
 	var msg errorJSON
-	dec := json.NewDecoder(body)
-	err = dec.Decode(&msg)
-	if err != nil {
-		return err
-	}
-	var errtxt string
-	errtxt += msg.Err
-	for i, v := range msg.Errors {
-		if i == len(msg.Errors)-1 {
-			errtxt += v.Err
-		} else {
-			errtxt += v.Err + ", "
+	//a body that isn't JSON (e.g. from a proxy or a 429) still
+	//yields an APIError with its status and raw body
+	if json.Unmarshal(body, &msg) == nil {
+		if msg.Err != "" {
+			apiErr.Messages = append(apiErr.Messages, msg.Err)
+		}
+		for _, v := range msg.Errors {
+			apiErr.Messages = append(apiErr.Messages, v.Err)
 		}
 	}
-	if errtxt == "" {
-		err = errors.New(status)
-	} else {
-		err = errors.New(status + ", Message(s): " + errtxt)
-	}
+	err = apiErr
 	return
 }
 
 //constructs BlockCypher URLs with parameters for requests
 func (api *API) buildURL(u string, params map[string]string) (target *url.URL, err error) {
-	target, err = url.Parse(baseURL + api.Coin + "/" + api.Chain + u)
+	target, err = url.Parse(api.base() + api.Coin + "/" + api.Chain + u)
 	if err != nil {
 		return
 	}
 	values := target.Query()
-	//Set parameters
+	//Set default parameters, then per-call parameters
+	if api.conf != nil {
+		for k, v := range api.conf.params {
+			values.Set(k, v)
+		}
+	}
 	for k, v := range params {
 		values.Set(k, v)
 	}
-	//add token to url, if present
-	if api.Token != "" {
+	//add token to url, if present and not sent by header
+	if api.Token != "" && api.tokenHeader() == "" {
 		values.Set("token", api.Token)
 	}
 	target.RawQuery = values.Encode()
 	return
 }
 
-// CheckUsage checks token usage
+// CheckUsage checks token usage.
+//The token is always sent as part of this request's path,
+//even with WithTokenHeader.
 func (api *API) CheckUsage() (usage TokenUsage, err error) {
-	u, err := url.Parse(baseURL + "tokens/" + api.Token)
+	return api.CheckUsageCtx(context.Background())
+}
+
+//CheckUsageCtx is like CheckUsage, but uses ctx
+//for the underlying HTTP request.
+func (api *API) CheckUsageCtx(ctx context.Context) (usage TokenUsage, err error) {
+	u, err := url.Parse(api.base() + "tokens/" + api.Token)
 	if err != nil {
+		err = api.redactErr(err)
 		return
 	}
-	err = getResponse(u, &usage)
+	err = api.getResponse(ctx, u, &usage)
 	return
 }
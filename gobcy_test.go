@@ -3,10 +3,15 @@
 package gobcy
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -15,12 +20,20 @@ var keys1, keys2 AddrKeychain
 var txhash1, txhash2 string
 var bcy API
 
+//liveToken is the environment variable holding the token
+//of the tests against the live API, which are skipped
+//without one.
+const liveToken = "BLOCKCYPHER_TOKEN"
+
 func TestMain(m *testing.M) {
 	//Set Coin/Chain to BlockCypher testnet
 	bcy.Coin = "bcy"
 	bcy.Chain = "test"
 	//Set Your Token
-	bcy.Token = "$TOKEN"
+	bcy.Token = os.Getenv(liveToken)
+	if bcy.Token == "" {
+		os.Exit(m.Run())
+	}
 	//Create/fund the test addresses
 	var err error
 	keys1, err = bcy.GenAddrKeychain()
@@ -42,9 +55,18 @@ func TestMain(m *testing.M) {
 	os.Exit(m.Run())
 }
 
+//live skips t unless the live API
+//tests have a token to run with.
+func live(t *testing.T) {
+	if bcy.Token == "" {
+		t.Skip("set " + liveToken + " to test against the live API")
+	}
+}
+
 //TestsGetTXConf runs first, to test
 //Confidence factor
 func TestGetTXConf(t *testing.T) {
+	live(t)
 	conf, err := bcy.GetTXConf(txhash2)
 	if err != nil {
 		t.Error("Error encountered: ", err)
@@ -53,6 +75,7 @@ func TestGetTXConf(t *testing.T) {
 }
 
 func TestUsage(t *testing.T) {
+	live(t)
 	usage, err := bcy.CheckUsage()
 	if err != nil {
 		t.Error("Error encountered: ", err)
@@ -60,7 +83,260 @@ func TestUsage(t *testing.T) {
 	t.Logf("%+v\n", usage)
 }
 
+func TestNewAPI(t *testing.T) {
+	var gotPath, gotUA, gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotUA, gotQuery = r.URL.Path, r.UserAgent(), r.URL.RawQuery
+		w.Write([]byte(`{"name":"BCY.test","height":42}`))
+	}))
+	defer srv.Close()
+	api := NewAPI("tok", "bcy", "test",
+		WithHTTPClient(srv.Client()),
+		WithBaseURL(srv.URL+"/v1"),
+		WithUserAgent("gobcy-test"),
+		WithParams(map[string]string{"limit": "5"}))
+	ch, err := api.GetChain()
+	if err != nil {
+		t.Fatal("GetChain error encountered: ", err)
+	}
+	if ch.Height != 42 || gotPath != "/v1/bcy/test" || gotUA != "gobcy-test" {
+		t.Errorf("Unexpected request/response: %+v %v %v", ch, gotPath, gotUA)
+	}
+	if gotQuery != "limit=5&token=tok" {
+		t.Error("Default params not sent, got query: ", gotQuery)
+	}
+}
+
+func TestContext(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+	api := NewAPI("", "bcy", "test", WithBaseURL(srv.URL))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := api.GetAddrCtx(ctx, "CBdrkAT8GVDvLmEJGZ1D3iJ1ZP4JuchqxP", nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Error("Expected context.DeadlineExceeded, got: ", err)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/bcy/test/txs/missing":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error": "Transaction missing not found."}`))
+		case "/bcy/test/txs/new":
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"tx":{"inputs":[],"outputs":[]},"errors":[{"error":"Not enough funds"}]}`))
+		default:
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	}))
+	defer srv.Close()
+	api := NewAPI("", "bcy", "test", WithBaseURL(srv.URL))
+	_, err := api.GetTX("missing", nil)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || !IsNotFound(err) {
+		t.Fatal("Expected 404 *APIError, got: ", err)
+	}
+	if apiErr.Path != "/bcy/test/txs/missing" || len(apiErr.Messages) != 1 {
+		t.Errorf("Unexpected APIError: %+v", apiErr)
+	}
+	_, err = api.GetChain()
+	if !IsRateLimited(err) || !errors.As(err, &apiErr) || apiErr.RetryAfter != 3*time.Second {
+		t.Error("Expected 429 *APIError with Retry-After, got: ", err)
+	}
+	skel, err := api.NewTX(TX{}, false)
+	if !errors.As(err, &apiErr) || apiErr.Messages[0] != "Not enough funds" || len(skel.Errors) != 1 {
+		t.Error("Expected TXSkel errors as *APIError, got: ", err)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	hits := map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits[r.URL.Path]++
+		if hits[r.URL.Path] < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	policy := RetryPolicy{MaxAttempts: 3, MinBackoff: time.Millisecond}
+	api := NewAPI("", "bcy", "test", WithBaseURL(srv.URL), WithRetry(policy))
+	if _, err := api.GetChain(); err != nil || hits["/bcy/test"] != 3 {
+		t.Error("Expected GetChain to succeed on third attempt, got: ", err, hits)
+	}
+	if _, err := api.NewTX(TX{}, false); err == nil || hits["/bcy/test/txs/new"] != 1 {
+		t.Error("Expected NewTX not to be retried by default, got: ", err, hits)
+	}
+	if _, err := api.PushTX("00"); err == nil || hits["/bcy/test/txs/push"] != 1 {
+		t.Error("Expected PushTX not to be retried by default, got: ", err, hits)
+	}
+	policy.RetryPOST = true
+	api = NewAPI("", "bcy", "test", WithBaseURL(srv.URL), WithRetry(policy))
+	if _, err := api.NewTX(TX{}, false); err != nil || hits["/bcy/test/txs/new"] != 3 {
+		t.Error("Expected NewTX to be retried with RetryPOST, got: ", err, hits)
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	l := NewLimiter(TokenUsage{
+		Limits: Usage{PerSec: 2, PerHour: 10, ConfPerHour: 1},
+		Hits:   Usage{PerHour: 7},
+	})
+	if d := l.reserve(limitAPI); d != 0 {
+		t.Error("Expected first request to be allowed, got wait: ", d)
+	}
+	if d := l.reserve(limitConf); d != 0 {
+		t.Error("Expected second request to be allowed, got wait: ", d)
+	}
+	if d := l.reserve(limitAPI); d <= 0 || d > time.Second {
+		t.Error("Expected third request within a second to wait, got: ", d)
+	}
+	left := l.Remaining()
+	if left.PerSec != 0 || left.PerHour != 1 || left.ConfPerHour != 0 || left.PerDay != -1 {
+		t.Errorf("Unexpected remaining budget: %+v", left)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var traced string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traced = r.Header.Get("X-Trace")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+	var calls []*Call
+	var results []CallResult
+	record := func(next Invoker) Invoker {
+		return func(ctx context.Context, c *Call) CallResult {
+			c.Header.Set("X-Trace", "abc")
+			res := next(ctx, c)
+			calls, results = append(calls, c), append(results, res)
+			return res
+		}
+	}
+	api := NewAPI("secret-token", "btc", "main", WithBaseURL(srv.URL+"/v1"), WithMiddleware(record))
+	api.GetAddrBal("1DEP8i3QJCsomS4BSMY2RpU1upv62aGvhD", nil)
+	api.GetMeta("1DEP8i3QJCsomS4BSMY2RpU1upv62aGvhD", "addr", true)
+	api.GetTXConf("43fa951e1bea87c282f6725cf8bdc08bb48761396c3af8dd5a41a085ab62acc9")
+	api.CheckUsage()
+	want := []string{"/addrs/{addr}/balance", "/addrs/{addr}/meta", "/txs/{hash}/confidence", "/tokens/{token}"}
+	if len(calls) != len(want) {
+		t.Fatal("Expected one Call per request, got: ", len(calls))
+	}
+	for i, c := range calls {
+		if c.Endpoint != want[i] || c.Coin != "btc" || results[i].StatusCode != 200 {
+			t.Errorf("Unexpected Call %+v, %+v", c, results[i])
+		}
+		if strings.Contains(c.URL, "secret-token") {
+			t.Error("Token not redacted from Call URL: ", c.URL)
+		}
+	}
+	if traced != "abc" {
+		t.Error("Middleware header not sent")
+	}
+}
+
+func TestTokenRedaction(t *testing.T) {
+	var gotHeader, gotQuery string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeader, gotQuery = r.Header.Get("X-Token"), r.URL.RawQuery
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "Token secret-token is invalid"}`))
+	}))
+	api := NewAPI("secret-token", "btc", "main", WithBaseURL(srv.URL), WithTokenHeader("X-Token"))
+	_, err := api.GetChain()
+	if gotHeader != "secret-token" || strings.Contains(gotQuery, "secret-token") {
+		t.Errorf("Expected token by header only, got header %q and query %q", gotHeader, gotQuery)
+	}
+	if !IsUnauthorized(err) || strings.Contains(err.Error(), "secret-token") {
+		t.Error("Expected redacted 401 *APIError, got: ", err)
+	}
+	_, err = api.CheckUsage()
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || strings.Contains(apiErr.Path, "secret-token") {
+		t.Error("Expected redacted APIError path, got: ", err)
+	}
+	srv.Close()
+	api = NewAPI("secret-token", "btc", "main", WithBaseURL(srv.URL))
+	_, err = api.GetChain()
+	if err == nil || strings.Contains(err.Error(), "secret-token") {
+		t.Error("Expected redacted transport error, got: ", err)
+	}
+	if strings.Contains(fmt.Sprintf("%v %+v", *api, *api), "secret-token") {
+		t.Error("Token printed with API")
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	p := AddrQuery{Before: 300000, Limit: 50, UnspentOnly: true, IncludeScript: true}.Params()
+	if len(p) != 4 || p["before"] != "300000" || p["limit"] != "50" || p["unspentOnly"] != "true" || p["includeScript"] != "true" {
+		t.Errorf("Unexpected AddrQuery params: %v", p)
+	}
+	p = TXQuery{InStart: 20, IncludeHex: true}.Params()
+	if len(p) != 2 || p["instart"] != "20" || p["includeHex"] != "true" {
+		t.Errorf("Unexpected TXQuery params: %v", p)
+	}
+	p = BlockQuery{TXStart: 1, Limit: 1}.Params()
+	if len(p) != 2 || p["txstart"] != "1" || p["limit"] != "1" {
+		t.Errorf("Unexpected BlockQuery params: %v", p)
+	}
+	sub := 0
+	p = DeriveQuery{SubchainIndex: &sub}.Params()
+	if len(p) != 1 || p["subchain_index"] != "0" {
+		t.Errorf("Unexpected DeriveQuery params: %v", p)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		batch := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/bcy/test/addrs/"), "/balance")
+		var items []string
+		for _, a := range strings.Split(batch, ";") {
+			switch a {
+			case "bad":
+				items = append(items, `{"error": "Address bad is invalid"}`)
+			case "gone":
+			default:
+				items = append(items, `{"address":"`+a+`","balance":1}`)
+			}
+		}
+		//reversed, as order isn't guaranteed
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+		}
+		w.Write([]byte("[" + strings.Join(items, ",") + "]"))
+	}))
+	defer srv.Close()
+	api := NewAPI("", "bcy", "test", WithBaseURL(srv.URL))
+	hashes := []string{"bad", "gone"}
+	for i := 0; i < 148; i++ {
+		hashes = append(hashes, fmt.Sprintf("addr%d", i))
+	}
+	addrs, errs, err := api.GetAddrBalBatch(hashes, nil)
+	if err != nil || requests != 2 {
+		t.Fatal("Expected two batched requests, got: ", requests, err)
+	}
+	if !strings.Contains(fmt.Sprint(errs[0]), "is invalid") || errs[1] == nil {
+		t.Error("Expected per-item errors, got: ", errs[0], errs[1])
+	}
+	for i := 2; i < len(hashes); i++ {
+		if errs[i] != nil || addrs[i].Address != hashes[i] {
+			t.Fatalf("Unexpected result %d: %+v, %v", i, addrs[i], errs[i])
+		}
+	}
+}
+
 func TestBlockchain(t *testing.T) {
+	live(t)
 	ch, err := bcy.GetChain()
 	if err != nil {
 		t.Error("GetChain error encountered: ", err)
@@ -93,6 +369,7 @@ func TestBlockchain(t *testing.T) {
 }
 
 func TestAddress(t *testing.T) {
+	live(t)
 	addr, err := bcy.GetAddrBal(keys1.Address, nil)
 	if err != nil {
 		t.Error("GetAddrBal error encountered: ", err)
@@ -111,6 +388,7 @@ func TestAddress(t *testing.T) {
 }
 
 func TestGenAddrMultisig(t *testing.T) {
+	live(t)
 	pubkeys := []string{
 		"02c716d071a76cbf0d29c29cacfec76e0ef8116b37389fb7a3e76d6d32cf59f4d3",
 		"033ef4d5165637d99b673bcdbb7ead359cee6afd7aaf78d3da9d2392ee4102c8ea",
@@ -127,6 +405,7 @@ func TestGenAddrMultisig(t *testing.T) {
 }
 
 func TestWallet(t *testing.T) {
+	live(t)
 	wal, err := bcy.CreateWallet(Wallet{Name: "testwallet",
 		Addresses: []string{keys1.Address}})
 	if err != nil {
@@ -166,6 +445,7 @@ func TestWallet(t *testing.T) {
 }
 
 func TestHDWallet(t *testing.T) {
+	live(t)
 	wal, err := bcy.CreateHDWallet(HDWallet{Name: "testhdwallet",
 		ExtPubKey: "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8"})
 	if err != nil {
@@ -200,6 +480,7 @@ func TestHDWallet(t *testing.T) {
 }
 
 func TestTX(t *testing.T) {
+	live(t)
 	txs, err := bcy.GetUnTX()
 	if err != nil {
 		t.Error("GetUnTX error encountered: ", err)
@@ -231,6 +512,7 @@ func TestTX(t *testing.T) {
 }
 
 func TestHook(t *testing.T) {
+	live(t)
 	hook, err := bcy.CreateHook(Hook{Event: "new-block", URL: "https://my.domain.com/api/callbacks/doublespend?secret=justbetweenus"})
 	if err != nil {
 		t.Error("PostHook error encountered: ", err)
@@ -248,6 +530,7 @@ func TestHook(t *testing.T) {
 }
 
 func TestPayFwd(t *testing.T) {
+	live(t)
 	pay, err := bcy.CreatePayFwd(PayFwd{Destination: keys1.Address})
 	if err != nil {
 		t.Error("CreatePayFwd error encountered: ", err)
@@ -270,6 +553,7 @@ func TestPayFwd(t *testing.T) {
 }
 
 func TestMeta(t *testing.T) {
+	live(t)
 	err := bcy.PutMeta(keys1.Address, "addr", true, map[string]string{"key": "value"})
 	if err != nil {
 		t.Error("PutMeta error encountered: ", err)
@@ -288,6 +572,7 @@ func TestMeta(t *testing.T) {
 }
 
 func TestAsset(t *testing.T) {
+	live(t)
 	oap1, err := bcy.GenAssetKeychain()
 	if err != nil {
 		t.Error("GenAssetKeychain error encountered: ", err)
@@ -0,0 +1,226 @@
+package gobcytest
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/blockcypher/gobcy/v2"
+)
+
+func (s *Server) routeAddrs(r *http.Request, segs []string, q url.Values) result {
+	switch {
+	case len(segs) == 1 && r.Method == "POST":
+		var req gobcy.AddrKeychain
+		if r.ContentLength != 0 {
+			if err := decode(r, &req); err != nil {
+				return fail(http.StatusBadRequest, "%v", err)
+			}
+		}
+		if len(req.PubKeys) > 0 {
+			addr, _, _, err := s.multisig(req.ScriptType, req.PubKeys)
+			if err != nil {
+				return fail(http.StatusBadRequest, "%v", err)
+			}
+			return ok(http.StatusOK, gobcy.AddrKeychain{Address: addr, PubKeys: req.PubKeys, ScriptType: req.ScriptType})
+		}
+		keys, err := s.newKey()
+		if err != nil {
+			return fail(http.StatusInternalServerError, "%v", err)
+		}
+		return ok(http.StatusOK, keys)
+	case len(segs) == 3 && segs[2] == "meta":
+		return s.routeMeta(r, "addr", segs[1], q)
+	case (len(segs) == 2 || len(segs) == 3) && r.Method == "GET":
+		mode := ""
+		if len(segs) == 3 {
+			mode = segs[2]
+			if mode != "balance" && mode != "full" {
+				break
+			}
+		}
+		if strings.Contains(segs[1], ";") {
+			var items []interface{}
+			for _, id := range strings.Split(segs[1], ";") {
+				items = append(items, addressable(s.addrView(id, mode, q).body))
+			}
+			return ok(http.StatusOK, items)
+		}
+		return s.addrView(segs[1], mode, q)
+	}
+	return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+}
+
+//addrRef is a TXRef along with the transaction it belongs to.
+type addrRef struct {
+	gobcy.TXRef
+	e      *entry
+	output bool
+}
+
+//addrView serves the balance, default and full views of id,
+//which may be an address or the name of a wallet or HD wallet.
+func (s *Server) addrView(id, mode string, q url.Values) result {
+	addr := gobcy.Addr{}
+	var addrs []string
+	if w, found := s.wallets[id]; found {
+		addr.Wallet = *w
+		addrs = w.Addresses
+	} else if hd, found := s.hdwallets[id]; found {
+		addr.HDWallet = gobcy.HDWallet{Name: hd.name, ExtPubKey: hd.xpub, SubchainIndexes: hd.subchains}
+		addrs = hd.addresses()
+	} else {
+		if _, err := s.pkScript(id); err != nil {
+			return fail(http.StatusBadRequest, "%v", err)
+		}
+		addr.Address = id
+		addrs = []string{id}
+	}
+	set := make(map[string]bool)
+	for _, a := range addrs {
+		set[a] = true
+	}
+	var refs []addrRef
+	var received, sent, unconfirmed int64
+	for _, e := range s.ordered() {
+		var txRefs []addrRef
+		for i, in := range e.msg.TxIn {
+			if isCoinbase(e.msg) {
+				break
+			}
+			prev := s.txs[in.PreviousOutPoint.Hash.String()].msg.TxOut[in.PreviousOutPoint.Index]
+			if as, _ := s.scriptInfo(prev.PkScript); len(as) == 1 && set[as[0]] {
+				ref := addrRef{e: e}
+				ref.Address, ref.TXInputN, ref.TXOutputN = as[0], i, -1
+				ref.Value.SetInt64(prev.Value)
+				txRefs = append(txRefs, ref)
+			}
+		}
+		for n, out := range e.msg.TxOut {
+			if as, _ := s.scriptInfo(out.PkScript); len(as) == 1 && set[as[0]] {
+				ref := addrRef{e: e, output: true}
+				ref.Address, ref.TXInputN, ref.TXOutputN = as[0], -1, n
+				ref.Value.SetInt64(out.Value)
+				ref.Spent, ref.SpentBy = e.spentBy[n] != "", e.spentBy[n]
+				if q.Get("includeScript") == "true" {
+					ref.Script = hex.EncodeToString(out.PkScript)
+				}
+				txRefs = append(txRefs, ref)
+			}
+		}
+		if len(txRefs) == 0 {
+			continue
+		}
+		if e.height < 0 {
+			addr.UnconfirmedNumTX++
+		} else {
+			addr.NumTX++
+		}
+		for i := range txRefs {
+			ref := &txRefs[i]
+			ref.TXHash = e.hash
+			ref.BlockHeight = e.height
+			ref.Confirmations = s.confirmations(e)
+			ref.Pref = e.pref
+			ref.Received = e.received
+			v := ref.Value.Int64()
+			if !ref.output {
+				v = -v
+			}
+			switch {
+			case e.height < 0:
+				ref.Confidence = 0.99
+				unconfirmed += v
+			case ref.output:
+				ref.Confirmed = s.blocks[e.height].header.Timestamp
+				received += v
+			default:
+				ref.Confirmed = s.blocks[e.height].header.Timestamp
+				sent -= v
+			}
+		}
+		refs = append(refs, txRefs...)
+	}
+	addr.TotalReceived.SetInt64(received)
+	addr.TotalSent.SetInt64(sent)
+	addr.Balance.SetInt64(received - sent)
+	addr.UnconfirmedBalance.SetInt64(unconfirmed)
+	addr.FinalBalance.SetInt64(received - sent + unconfirmed)
+	addr.FinalNumTX = addr.NumTX + addr.UnconfirmedNumTX
+	if mode == "balance" {
+		return ok(http.StatusOK, addr)
+	}
+	//apply the filters, then page confirmed refs by limit
+	before, after := intParam(q, "before", -1), intParam(q, "after", -1)
+	confs := intParam(q, "confirmations", 0)
+	keep := func(e *entry) bool {
+		if before >= 0 && (e.height < 0 || e.height >= before) {
+			return false
+		}
+		if after >= 0 && e.height <= after {
+			return false
+		}
+		return s.confirmations(e) >= confs
+	}
+	if mode == "full" {
+		limit := intParam(q, "limit", 10)
+		txq := url.Values{"limit": {q.Get("txlimit")}, "includeHex": {q.Get("includeHex")}}
+		if txq.Get("limit") == "" {
+			txq.Del("limit")
+		}
+		seen := make(map[string]bool)
+		addr.TXs = []gobcy.TX{}
+		for _, ref := range refs {
+			if seen[ref.e.hash] || !keep(ref.e) {
+				continue
+			}
+			seen[ref.e.hash] = true
+			if len(addr.TXs) == limit {
+				addr.HasMore = true
+				break
+			}
+			addr.TXs = append(addr.TXs, s.txView(ref.e, txq))
+		}
+		return ok(http.StatusOK, addr)
+	}
+	limit := intParam(q, "limit", 50)
+	var confirmed int
+	for _, ref := range refs {
+		if !keep(ref.e) || (q.Get("unspentOnly") == "true" && (!ref.output || ref.Spent)) {
+			continue
+		}
+		if ref.e.height < 0 {
+			addr.UnconfirmedTXRefs = append(addr.UnconfirmedTXRefs, ref.TXRef)
+			continue
+		}
+		if confirmed == limit {
+			addr.HasMore = true
+			break
+		}
+		confirmed++
+		addr.TXRefs = append(addr.TXRefs, ref.TXRef)
+	}
+	return ok(http.StatusOK, addr)
+}
+
+func (s *Server) faucet(r *http.Request) result {
+	if !(s.Coin == "bcy" && s.Chain == "test") && !(s.Coin == "btc" && s.Chain == "test3") {
+		return fail(http.StatusBadRequest, "Faucet is only available on bcy/test and btc/test3")
+	}
+	var req struct {
+		Address string `json:"address"`
+		Amount  int64  `json:"amount"`
+	}
+	if err := decode(r, &req); err != nil {
+		return fail(http.StatusBadRequest, "%v", err)
+	}
+	if req.Amount <= 0 {
+		return fail(http.StatusBadRequest, "Amount must be positive")
+	}
+	hash, err := s.fund(req.Address, req.Amount)
+	if err != nil {
+		return fail(http.StatusBadRequest, "%v", err)
+	}
+	return ok(http.StatusOK, map[string]string{"tx_ref": hash})
+}
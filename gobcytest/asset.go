@@ -0,0 +1,234 @@
+package gobcytest
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+//Version bytes of Open Assets addresses and asset IDs.
+const (
+	oapAddrVersion  = 0x13
+	oapAssetVersion = 0x17
+)
+
+//oapDust is the value of each output of an asset transaction.
+const oapDust = 600
+
+//asset is an Open Asset. Issuing and transferring it adds
+//a transaction to the ledger with a marker output and dust
+//outputs to the receivers, but like Fund it doesn't spend
+//any outputs: asset balances are tracked apart from them.
+type asset struct {
+	id       string
+	txs      []*oapTX
+	balances map[string]int64
+}
+
+//oapTX is the JSON of a gobcy.OAPTX.
+type oapTX struct {
+	Ver         int         `json:"ver"`
+	AssetID     string      `json:"assetid"`
+	Hash        string      `json:"hash"`
+	Confirmed   time.Time   `json:"confirmed,omitempty"`
+	Received    time.Time   `json:"received"`
+	Metadata    string      `json:"oap_meta,omitempty"`
+	DoubleSpend bool        `json:"double_spend"`
+	Inputs      []oapInput  `json:"inputs"`
+	Outputs     []oapOutput `json:"outputs"`
+}
+
+type oapInput struct {
+	PrevHash    string `json:"prev_hash"`
+	OutputIndex int    `json:"output_index"`
+	OAPAddress  string `json:"address"`
+	OutputValue int64  `json:"output_value"`
+}
+
+type oapOutput struct {
+	OAPAddress      string `json:"address"`
+	Value           int64  `json:"value"`
+	OrigOutputIndex int    `json:"original_output_index"`
+}
+
+//oapAddress returns the OAP address of a P2PKH
+//address with the given key hash.
+func (s *Server) oapAddress(keyHash []byte) string {
+	return base58.CheckEncode(append([]byte{s.params.PubKeyHashAddrID}, keyHash...), oapAddrVersion)
+}
+
+//checkOAPAddress checks that addr is an OAP address for the chain.
+func (s *Server) checkOAPAddress(addr string) error {
+	payload, ver, err := base58.CheckDecode(addr)
+	if err != nil || ver != oapAddrVersion || len(payload) != 21 || payload[0] != s.params.PubKeyHashAddrID {
+		return errors.New("Invalid OAP address " + addr)
+	}
+	return nil
+}
+
+func (s *Server) routeAssets(r *http.Request, segs []string) result {
+	switch {
+	case len(segs) == 1 && segs[0] == "addrs" && r.Method == "POST":
+		keys, err := s.newKey()
+		if err != nil {
+			return fail(http.StatusInternalServerError, "%v", err)
+		}
+		pub, _ := hex.DecodeString(keys.Public)
+		keys.OriginalAddress = keys.Address
+		keys.OAPAddress = s.oapAddress(btcutil.Hash160(pub))
+		keys.Address = ""
+		return ok(http.StatusOK, keys)
+	case len(segs) == 1 && segs[0] == "issue" && r.Method == "POST":
+		return s.assetTX(r, "")
+	case len(segs) == 2 && segs[1] == "transfer" && r.Method == "POST":
+		return s.assetTX(r, segs[0])
+	}
+	if len(segs) < 2 {
+		return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+	}
+	a, found := s.assets[segs[0]]
+	if !found {
+		return fail(http.StatusNotFound, "Asset %s not found.", segs[0])
+	}
+	switch {
+	case len(segs) == 2 && segs[1] == "txs" && r.Method == "GET":
+		hashes := []string{}
+		for i := len(a.txs) - 1; i >= 0; i-- {
+			hashes = append(hashes, a.txs[i].Hash)
+		}
+		return ok(http.StatusOK, hashes)
+	case len(segs) == 3 && segs[1] == "txs" && r.Method == "GET":
+		for _, tx := range a.txs {
+			if tx.Hash == segs[2] {
+				return ok(http.StatusOK, s.oapView(tx))
+			}
+		}
+		return fail(http.StatusNotFound, "Transaction %s not found.", segs[2])
+	case len(segs) == 3 && segs[1] == "addrs" && r.Method == "GET":
+		if err := s.checkOAPAddress(segs[2]); err != nil {
+			return fail(http.StatusBadRequest, "%v", err)
+		}
+		addr := gobcy.Addr{Address: segs[2]}
+		var received, sent int64
+		for _, tx := range a.txs {
+			involved := false
+			for _, in := range tx.Inputs {
+				if in.OAPAddress == segs[2] {
+					sent += in.OutputValue
+					involved = true
+				}
+			}
+			for _, out := range tx.Outputs {
+				if out.OAPAddress == segs[2] {
+					received += out.Value
+					involved = true
+				}
+			}
+			if involved {
+				addr.NumTX++
+			}
+		}
+		addr.TotalReceived.SetInt64(received)
+		addr.TotalSent.SetInt64(sent)
+		addr.Balance.SetInt64(received - sent)
+		addr.FinalBalance.SetInt64(received - sent)
+		addr.FinalNumTX = addr.NumTX
+		return ok(http.StatusOK, addr)
+	}
+	return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+}
+
+//assetTX issues a new asset, if id is empty, or
+//transfers asset id.
+func (s *Server) assetTX(r *http.Request, id string) result {
+	var req gobcy.OAPIssue
+	if err := decode(r, &req); err != nil {
+		return fail(http.StatusBadRequest, "%v", err)
+	}
+	raw, err := hex.DecodeString(req.Priv)
+	if err != nil || len(raw) != 32 {
+		return fail(http.StatusBadRequest, "Invalid private key")
+	}
+	_, pub := btcec.PrivKeyFromBytes(raw)
+	keyHash := btcutil.Hash160(pub.SerializeCompressed())
+	from := s.oapAddress(keyHash)
+	if err = s.checkOAPAddress(req.ToAddr); err != nil {
+		return fail(http.StatusBadRequest, "%v", err)
+	}
+	amount := req.Amount.Int64()
+	if amount <= 0 {
+		return fail(http.StatusBadRequest, "Amount must be positive")
+	}
+	tx := &oapTX{Ver: 1, Received: time.Now(), Metadata: req.Metadata, Inputs: []oapInput{}}
+	var a *asset
+	if id == "" {
+		//asset IDs derive from the issuer's output script
+		id = base58.CheckEncode(btcutil.Hash160(p2pkhCode(keyHash)), oapAssetVersion)
+		if a = s.assets[id]; a == nil {
+			a = &asset{id: id, balances: make(map[string]int64)}
+			s.assets[id] = a
+		}
+	} else {
+		if a = s.assets[id]; a == nil {
+			return fail(http.StatusNotFound, "Asset %s not found.", id)
+		}
+		balance := a.balances[from]
+		if balance < amount {
+			return fail(http.StatusBadRequest, "Not enough of asset %s in %s: have %d, need %d", id, from, balance, amount)
+		}
+		tx.Inputs = append(tx.Inputs, oapInput{PrevHash: a.lastTX(from), OAPAddress: from, OutputValue: balance})
+		if balance > amount {
+			tx.Outputs = append(tx.Outputs, oapOutput{OAPAddress: from, Value: balance - amount, OrigOutputIndex: 1})
+		}
+		a.balances[from] = 0
+	}
+	tx.AssetID = id
+	tx.Outputs = append([]oapOutput{{OAPAddress: req.ToAddr, Value: amount}}, tx.Outputs...)
+	s.seq++
+	msg := coinbase(s.seq)
+	for _, out := range tx.Outputs {
+		payload, _, _ := base58.CheckDecode(out.OAPAddress)
+		msg.AddTxOut(wire.NewTxOut(oapDust, p2pkhCode(payload[1:])))
+	}
+	marker, _ := txscript.NullDataScript(append([]byte("OA\x01\x00"), []byte(req.Metadata)...))
+	msg.AddTxOut(wire.NewTxOut(0, marker))
+	e, err := s.insert(msg, "high")
+	if err != nil {
+		return fail(http.StatusInternalServerError, "%v", err)
+	}
+	tx.Hash = e.hash
+	for _, out := range tx.Outputs {
+		a.balances[out.OAPAddress] += out.Value
+	}
+	a.txs = append(a.txs, tx)
+	return ok(http.StatusOK, s.oapView(tx))
+}
+
+//oapView returns tx with its confirmation time from the ledger.
+func (s *Server) oapView(tx *oapTX) oapTX {
+	v := *tx
+	if e := s.txs[tx.Hash]; e != nil && e.height >= 0 {
+		v.Confirmed = s.blocks[e.height].header.Timestamp
+	}
+	return v
+}
+
+//lastTX returns the hash of the last transaction paying addr.
+func (a *asset) lastTX(addr string) string {
+	for i := len(a.txs) - 1; i >= 0; i-- {
+		for _, out := range a.txs[i].Outputs {
+			if out.OAPAddress == addr {
+				return a.txs[i].Hash
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,107 @@
+package gobcytest
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/blockcypher/gobcy/v2"
+)
+
+//fee tiers reported by the fake chain, in satoshis per kb.
+const (
+	highFee   = 40000
+	mediumFee = 20000
+	lowFee    = 10000
+)
+
+func (s *Server) chainView() gobcy.Blockchain {
+	tip := s.tip()
+	ch := gobcy.Blockchain{
+		Name:             strings.ToUpper(s.Coin) + "." + s.Chain,
+		Height:           tip.height,
+		Hash:             tip.hash,
+		Time:             tip.header.Timestamp,
+		PeerCount:        8,
+		HighFee:          highFee,
+		MediumFee:        mediumFee,
+		LowFee:           lowFee,
+		UnconfirmedCount: len(s.mempool),
+		LastForkHeight:   s.forkHeight,
+		LastForkHash:     s.forkHash,
+	}
+	if tip.height > 0 {
+		ch.PrevHash = tip.header.PrevBlock.String()
+	}
+	return ch
+}
+
+//getBlocks serves /blocks/{id}, where id may
+//be a semicolon-separated batch.
+func (s *Server) getBlocks(ids string, q url.Values) result {
+	if strings.Contains(ids, ";") {
+		var items []interface{}
+		for _, id := range strings.Split(ids, ";") {
+			items = append(items, addressable(s.getBlock(id, q).body))
+		}
+		return ok(http.StatusOK, items)
+	}
+	return s.getBlock(ids, q)
+}
+
+func (s *Server) getBlock(id string, q url.Values) result {
+	b := s.lookupBlock(id)
+	if b == nil {
+		return fail(http.StatusNotFound, "Block %s not found.", id)
+	}
+	return ok(http.StatusOK, s.blockView(b, q))
+}
+
+func (s *Server) blockView(b *block, q url.Values) gobcy.Block {
+	bl := gobcy.Block{
+		Hash:         b.hash,
+		Height:       b.height,
+		Depth:        s.tip().height - b.height,
+		Chain:        strings.ToUpper(s.Coin) + "." + s.Chain,
+		Ver:          int(b.header.Version),
+		Time:         b.header.Timestamp,
+		ReceivedTime: b.received,
+		Bits:         int(b.header.Bits),
+		Nonce:        int(b.header.Nonce),
+		NumTX:        len(b.txids),
+		MerkleRoot:   b.header.MerkleRoot.String(),
+	}
+	if b.height > 0 {
+		bl.PrevBlock = b.header.PrevBlock.String()
+	}
+	var total, fees int64
+	for _, id := range b.txids {
+		e := s.txs[id]
+		bl.Size += e.msg.SerializeSize()
+		bl.VirtualSize += vsize(e.msg)
+		for _, out := range e.msg.TxOut {
+			total += out.Value
+		}
+		fees += s.fee(e)
+	}
+	bl.Total.SetInt64(total)
+	bl.Fees.SetInt64(fees)
+	start := intParam(q, "txstart", 0)
+	limit := intParam(q, "limit", 20)
+	if start < 0 {
+		start = 0
+	}
+	end := start + limit
+	if end > len(b.txids) {
+		end = len(b.txids)
+	}
+	if start < end {
+		bl.TXids = append([]string{}, b.txids[start:end]...)
+	}
+	if end < len(b.txids) {
+		bl.NextTXs = s.URL() + s.Coin + "/" + s.Chain + "/blocks/" + b.hash +
+			"?txstart=" + strconv.Itoa(end) + "&limit=" + strconv.Itoa(limit)
+	}
+	return bl
+}
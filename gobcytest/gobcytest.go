@@ -0,0 +1,298 @@
+//Package gobcytest provides an in-process fake of the BlockCypher API,
+//for testing code built on gobcy without network access or a token.
+//
+//A Server keeps an in-memory ledger of real, serialized transactions
+//and blocks, which you seed by funding addresses and mining blocks:
+//	srv := gobcytest.NewServer("bcy", "test")
+//	defer srv.Close()
+//	bc := srv.API("any-token")
+//	keys, _ := bc.GenAddrKeychain()
+//	srv.Fund(keys.Address, 100000)
+//	srv.Mine()
+//	addr, _ := bc.GetAddrBal(keys.Address, nil)
+//It implements the chain, blocks, addrs, txs (new/send/push/decode),
+//wallets, HD wallets, hooks, payments, meta and oap endpoints closely
+//enough to exercise the real client code. Transactions sent through
+//it are checked with btcd's script engine, and double-spends are
+//rejected unless they replace unconfirmed transactions signaling
+//BIP-125 at a higher fee. Hooks and payment forwards are stored,
+//but never called back or forwarded.
+package gobcytest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+//Server is a fake BlockCypher API for a single coin/chain,
+//listening on a local httptest.Server.
+type Server struct {
+	Coin, Chain string
+
+	srv    *httptest.Server
+	params *chaincfg.Params
+
+	mu         sync.Mutex
+	txs        map[string]*entry
+	mempool    []string
+	blocks     []*block
+	forkHeight int
+	forkHash   string
+	redeem     map[string][]byte
+	wallets    map[string]*gobcy.Wallet
+	hdwallets  map[string]*hdWallet
+	hooks      []gobcy.Hook
+	payfwds    []gobcy.PayFwd
+	meta       map[string]map[string]string
+	assets     map[string]*asset
+	limits     gobcy.Usage
+	hits       map[string]*usage
+	seq        int
+}
+
+//NewServer starts a Server for the given coin/chain, which
+//can be any coin/chain supported by gobcy.ChainParams.
+//It panics on any other coin/chain. The ledger
+//starts with a genesis block at height 0.
+func NewServer(coin, chain string) *Server {
+	params, err := gobcy.ChainParams(coin, chain)
+	if err != nil {
+		panic("gobcytest: unsupported coin/chain " + coin + "/" + chain)
+	}
+	s := &Server{
+		Coin:      coin,
+		Chain:     chain,
+		params:    params,
+		txs:       make(map[string]*entry),
+		redeem:    make(map[string][]byte),
+		wallets:   make(map[string]*gobcy.Wallet),
+		hdwallets: make(map[string]*hdWallet),
+		meta:      make(map[string]map[string]string),
+		assets:    make(map[string]*asset),
+		limits:    gobcy.Usage{PerSec: 3, PerHour: 200, PerDay: 2000, HooksPerHour: 200, ConfPerHour: 15, Hooks: 200, PayFwds: 200},
+		hits:      make(map[string]*usage),
+	}
+	s.mine()
+	s.srv = httptest.NewServer(s)
+	return s
+}
+
+//URL returns the base URL of the Server, for use with gobcy.WithBaseURL.
+func (s *Server) URL() string {
+	return s.srv.URL + "/v1/"
+}
+
+//API returns a *gobcy.API for the Server's coin/chain using
+//token, with any extra Options applied after those pointing
+//it at the Server.
+func (s *Server) API(token string, opts ...gobcy.Option) *gobcy.API {
+	opts = append([]gobcy.Option{gobcy.WithBaseURL(s.URL()), gobcy.WithHTTPClient(s.srv.Client())}, opts...)
+	return gobcy.NewAPI(token, s.Coin, s.Chain, opts...)
+}
+
+//Close shuts down the Server.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+//SetLimits sets the token limits reported by /tokens.
+func (s *Server) SetLimits(limits gobcy.Usage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits
+}
+
+//usage counts the requests made with a token.
+type usage struct {
+	hour, day time.Time
+	hits      gobcy.Usage
+}
+
+//ServeHTTP implements http.Handler, routing
+//requests to the fake endpoints.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	path := strings.TrimPrefix(r.URL.Path, "/v1/")
+	if path == r.URL.Path {
+		writeJSON(w, fail(http.StatusNotFound, "Not found"))
+		return
+	}
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	if segs[0] == "tokens" && len(segs) == 2 && r.Method == "GET" {
+		writeJSON(w, s.tokenUsage(segs[1]))
+		return
+	}
+	s.count(r)
+	if len(segs) < 2 || segs[0] != s.Coin || segs[1] != s.Chain {
+		writeJSON(w, fail(http.StatusNotFound, "Unknown coin/chain %q", strings.Join(segs[:min(2, len(segs))], "/")))
+		return
+	}
+	writeJSON(w, s.route(r, segs[2:]))
+}
+
+//result is the status and JSON body of a response.
+type result struct {
+	status int
+	body   interface{}
+}
+
+//ok returns a successful result.
+func ok(status int, body interface{}) result {
+	return result{status, body}
+}
+
+//fail returns a result carrying a BlockCypher-style error.
+func fail(status int, format string, args ...interface{}) result {
+	return result{status, map[string]string{"error": fmt.Sprintf(format, args...)}}
+}
+
+func writeJSON(w http.ResponseWriter, res result) {
+	if res.body == nil {
+		w.WriteHeader(res.status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(res.status)
+	json.NewEncoder(w).Encode(addressable(res.body))
+}
+
+//addressable returns a pointer to a copy of v, so that
+//the big.Int fields of gobcy types, whose MarshalJSON
+//has a pointer receiver, encode as numbers.
+func addressable(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr {
+		return v
+	}
+	p := reflect.New(rv.Type())
+	p.Elem().Set(rv)
+	return p.Interface()
+}
+
+//route dispatches a request by method and path segments,
+//relative to the coin/chain.
+func (s *Server) route(r *http.Request, segs []string) result {
+	q := r.URL.Query()
+	if len(segs) == 1 && segs[0] == "" {
+		segs = nil
+	}
+	n := len(segs)
+	at := func(i int) string {
+		if i < n {
+			return segs[i]
+		}
+		return ""
+	}
+	switch {
+	case n == 0 && r.Method == "GET":
+		return ok(http.StatusOK, s.chainView())
+	case at(0) == "blocks" && n == 2 && r.Method == "GET":
+		return s.getBlocks(segs[1], q)
+	case at(0) == "blocks" && n == 3 && segs[2] == "meta":
+		return s.routeMeta(r, "block", segs[1], q)
+	case at(0) == "addrs":
+		return s.routeAddrs(r, segs, q)
+	case at(0) == "faucet" && n == 1 && r.Method == "POST":
+		return s.faucet(r)
+	case at(0) == "txs":
+		return s.routeTXs(r, segs, q)
+	case at(0) == "wallets" && at(1) == "hd":
+		return s.routeHDWallets(r, segs[2:], q)
+	case at(0) == "wallets":
+		return s.routeWallets(r, segs[1:], q)
+	case at(0) == "hooks":
+		return s.routeHooks(r, segs[1:])
+	case at(0) == "payments":
+		return s.routePayFwds(r, segs[1:], q)
+	case at(0) == "oap":
+		return s.routeAssets(r, segs[1:])
+	}
+	return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+}
+
+//decode reads a JSON request body into v.
+func decode(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("Invalid JSON body: %v", err)
+	}
+	return nil
+}
+
+//count records a request against the usage of its token.
+func (s *Server) count(r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		return
+	}
+	u := s.usageOf(token)
+	u.hits.PerHour++
+	u.hits.PerDay++
+	if strings.HasSuffix(r.URL.Path, "/confidence") {
+		u.hits.ConfPerHour++
+	}
+	if r.Method == "POST" && strings.HasSuffix(r.URL.Path, "/hooks") {
+		u.hits.HooksPerHour++
+	}
+}
+
+//usageOf returns the usage of token, resetting
+//its counts when the hour or day has passed.
+func (s *Server) usageOf(token string) *usage {
+	now := time.Now()
+	u, found := s.hits[token]
+	if !found {
+		u = &usage{}
+		s.hits[token] = u
+	}
+	if h := now.Truncate(time.Hour); !h.Equal(u.hour) {
+		u.hour = h
+		u.hits.PerHour, u.hits.HooksPerHour, u.hits.ConfPerHour = 0, 0, 0
+	}
+	if d := now.Truncate(24 * time.Hour); !d.Equal(u.day) {
+		u.day = d
+		u.hits.PerDay = 0
+	}
+	return u
+}
+
+func (s *Server) tokenUsage(token string) result {
+	u := s.usageOf(token)
+	hits := u.hits
+	hits.Hooks = len(s.hooks)
+	hits.PayFwds = len(s.payfwds)
+	return ok(http.StatusOK, gobcy.TokenUsage{Limits: s.limits, Hits: hits})
+}
+
+//newID returns a unique identifier with the given prefix.
+func (s *Server) newID(prefix string) string {
+	s.seq++
+	return prefix + strconv.Itoa(s.seq)
+}
+
+//intParam returns the integer URL parameter k,
+//or def if it is missing or invalid.
+func intParam(q url.Values, k string, def int) int {
+	if v, err := strconv.Atoi(q.Get(k)); err == nil {
+		return v
+	}
+	return def
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
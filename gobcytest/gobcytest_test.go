@@ -0,0 +1,529 @@
+package gobcytest
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+//fundedKeys returns a new keychain funded with amount
+//by the faucet, confirmed in a new block.
+func fundedKeys(t *testing.T, srv *Server, bc *gobcy.API, amount int) gobcy.AddrKeychain {
+	keys, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	if _, err = bc.Faucet(keys, amount); err != nil {
+		t.Fatal("Faucet error encountered: ", err)
+	}
+	srv.Mine()
+	return keys
+}
+
+func TestServerTX(t *testing.T) {
+	srv := NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	keys1 := fundedKeys(t, srv, bc, 1e5)
+	keys2 := fundedKeys(t, srv, bc, 2e5)
+	skel, err := bc.NewTX(gobcy.TempNewTX(keys2.Address, keys1.Address, *big.NewInt(45000)), true)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if len(skel.ToSign) != 1 || len(skel.ToSignTX) != 1 {
+		t.Fatalf("NewTX returned %d tosign and %d tosign_tx, expected 1", len(skel.ToSign), len(skel.ToSignTX))
+	}
+	pre, _ := hex.DecodeString(skel.ToSignTX[0])
+	if hex.EncodeToString(chainhash.DoubleHashB(pre)) != skel.ToSign[0] {
+		t.Error("tosign is not the double SHA-256 of tosign_tx")
+	}
+	if err = skel.Sign([]string{keys2.Private}); err != nil {
+		t.Fatal("*TXSkel.Sign error encountered: ", err)
+	}
+	sent, err := bc.SendTX(skel)
+	if err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+	fees := sent.Trans.Fees.Int64()
+	if fees <= 0 {
+		t.Error("SendTX returned no fees")
+	}
+	unconf, err := bc.GetUnTX()
+	if err != nil || len(unconf) != 1 || unconf[0].Hash != sent.Trans.Hash {
+		t.Errorf("GetUnTX returned %v, %v; expected only %s", unconf, err, sent.Trans.Hash)
+	}
+	srv.Mine()
+	addr1, err := bc.GetAddrBal(keys1.Address, nil)
+	if err != nil {
+		t.Fatal("GetAddrBal error encountered: ", err)
+	}
+	if addr1.Balance.Int64() != 145000 || addr1.NumTX != 2 {
+		t.Errorf("GetAddrBal returned balance %v with %d txs, expected 145000 with 2", &addr1.Balance, addr1.NumTX)
+	}
+	addr2, err := bc.GetAddrBal(keys2.Address, nil)
+	if err != nil {
+		t.Fatal("GetAddrBal error encountered: ", err)
+	}
+	if addr2.Balance.Int64() != 2e5-45000-fees {
+		t.Errorf("GetAddrBal returned balance %v, expected %d", &addr2.Balance, 2e5-45000-fees)
+	}
+	tx, err := bc.GetTX(sent.Trans.Hash, map[string]string{"includeHex": "true"})
+	if err != nil {
+		t.Fatal("GetTX error encountered: ", err)
+	}
+	if tx.Confirmations != 1 || tx.BlockHeight != srv.Height() {
+		t.Errorf("GetTX returned %d confirmations at height %d", tx.Confirmations, tx.BlockHeight)
+	}
+	//the same transaction can't be pushed twice
+	if _, err = bc.PushTX(tx.Hex); err == nil {
+		t.Error("Expected error pushing a transaction twice, did not receive one")
+	}
+	decoded, err := bc.DecodeTX(tx.Hex)
+	if err != nil || decoded.Hash != tx.Hash {
+		t.Errorf("DecodeTX returned %v, %v; expected %s", decoded.Hash, err, tx.Hash)
+	}
+	//spending more than the balance fails
+	_, err = bc.NewTX(gobcy.TempNewTX(keys1.Address, keys2.Address, *big.NewInt(1e6)), false)
+	if err == nil {
+		t.Error("Expected error spending more than the balance, did not receive one")
+	}
+	//a wrong key is refused, and a wrong signature rejected
+	skel, err = bc.NewTX(gobcy.TempNewTX(keys1.Address, keys2.Address, *big.NewInt(1000)), false)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = skel.Sign([]string{keys2.Private}); err == nil {
+		t.Error("Expected error signing with a key not matching the input, did not receive one")
+	}
+	if err = skel.Sign([]string{keys1.Private}); err != nil {
+		t.Fatal("*TXSkel.Sign error encountered: ", err)
+	}
+	skel.PubKeys[0] = keys2.Public
+	if _, err = bc.SendTX(skel); err == nil {
+		t.Error("Expected error sending a badly signed transaction, did not receive one")
+	}
+}
+
+func TestServerMultisig(t *testing.T) {
+	srv := NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	pubkeys := []string{
+		"02c716d071a76cbf0d29c29cacfec76e0ef8116b37389fb7a3e76d6d32cf59f4d3",
+		"033ef4d5165637d99b673bcdbb7ead359cee6afd7aaf78d3da9d2392ee4102c8ea",
+		"022b8934cc41e76cb4286b9f3ed57e2d27798395b04dd23711981a77dc216df8ca",
+	}
+	response, err := bc.GenAddrMultisig(gobcy.AddrKeychain{PubKeys: pubkeys, ScriptType: "multisig-2-of-3"})
+	if err != nil {
+		t.Fatal("GenAddrMultisig error encountered: ", err)
+	}
+	if response.Address != "De2gwq9GvNgvKgHCYRMKnPqss3pzWGSHiH" {
+		t.Error("GenAddrMultisig response does not match expected address")
+	}
+	//fund a 2-of-3 address, then spend from it
+	var keys []gobcy.AddrKeychain
+	pubkeys = nil
+	for i := 0; i < 3; i++ {
+		k, err := bc.GenAddrKeychain()
+		if err != nil {
+			t.Fatal("GenAddrKeychain error encountered: ", err)
+		}
+		keys = append(keys, k)
+		pubkeys = append(pubkeys, k.Public)
+	}
+	funder := fundedKeys(t, srv, bc, 1e6)
+	temp, err := gobcy.TempMultiTX(funder.Address, "", *big.NewInt(5e5), 2, pubkeys)
+	if err != nil {
+		t.Fatal("TempMultiTX error encountered: ", err)
+	}
+	skel, err := bc.NewTX(temp, false)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = skel.Sign([]string{funder.Private}); err != nil {
+		t.Fatal("*TXSkel.Sign error encountered: ", err)
+	}
+	if _, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+	srv.Mine()
+	temp, err = gobcy.TempMultiTX("", funder.Address, *big.NewInt(2e5), 2, pubkeys)
+	if err != nil {
+		t.Fatal("TempMultiTX error encountered: ", err)
+	}
+	skel, err = bc.NewTX(temp, false)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if len(skel.ToSign) != 2 {
+		t.Fatalf("NewTX returned %d tosign, expected 2", len(skel.ToSign))
+	}
+	if err = skel.Sign([]string{keys[0].Private, keys[2].Private}); err != nil {
+		t.Fatal("*TXSkel.Sign error encountered: ", err)
+	}
+	if _, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+}
+
+func TestServerAddrPaging(t *testing.T) {
+	srv := NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	keys, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	for i := 0; i < 5; i++ {
+		srv.Fund(keys.Address, 1000)
+		srv.Mine()
+	}
+	srv.Fund(keys.Address, 1000)
+	addr, err := bc.GetAddr(keys.Address, map[string]string{"limit": "2"})
+	if err != nil {
+		t.Fatal("GetAddr error encountered: ", err)
+	}
+	if len(addr.TXRefs) != 2 || len(addr.UnconfirmedTXRefs) != 1 || !addr.HasMore {
+		t.Fatalf("GetAddr returned %d refs and %d unconfirmed, hasMore %v", len(addr.TXRefs), len(addr.UnconfirmedTXRefs), addr.HasMore)
+	}
+	if addr.FinalBalance.Int64() != 6000 || addr.Balance.Int64() != 5000 {
+		t.Errorf("GetAddr returned balance %v and final balance %v", &addr.Balance, &addr.FinalBalance)
+	}
+	next, err := bc.GetAddrNext(addr)
+	if err != nil {
+		t.Fatal("GetAddrNext error encountered: ", err)
+	}
+	if len(next.TXRefs) != 3 || next.HasMore || len(next.UnconfirmedTXRefs) != 0 {
+		t.Errorf("GetAddrNext returned %d refs, hasMore %v", len(next.TXRefs), next.HasMore)
+	}
+	full, err := bc.GetAddrFull(keys.Address, map[string]string{"limit": "4"})
+	if err != nil {
+		t.Fatal("GetAddrFull error encountered: ", err)
+	}
+	if len(full.TXs) != 4 || !full.HasMore {
+		t.Errorf("GetAddrFull returned %d txs, hasMore %v", len(full.TXs), full.HasMore)
+	}
+	//batches are served in order
+	addrs, errs, err := bc.GetAddrBalBatch([]string{keys.Address, "notanaddress"}, nil)
+	if err != nil {
+		t.Fatal("GetAddrBalBatch error encountered: ", err)
+	}
+	if addrs[0].Address != keys.Address || errs[0] != nil || errs[1] == nil {
+		t.Errorf("GetAddrBalBatch returned %v, %v", addrs, errs)
+	}
+}
+
+func TestServerBlocks(t *testing.T) {
+	srv := NewServer("btc", "test3")
+	defer srv.Close()
+	bc := srv.API("token")
+	keys, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	for i := 0; i < 3; i++ {
+		srv.Fund(keys.Address, 1000)
+	}
+	height := srv.Mine()
+	ch, err := bc.GetChain()
+	if err != nil {
+		t.Fatal("GetChain error encountered: ", err)
+	}
+	if ch.Height != height || ch.Name != "BTC.test3" {
+		t.Errorf("GetChain returned %s at height %d, expected height %d", ch.Name, ch.Height, height)
+	}
+	bl, err := bc.GetBlock(0, ch.Hash, map[string]string{"txstart": "0", "limit": "2"})
+	if err != nil {
+		t.Fatal("GetBlock error encountered: ", err)
+	}
+	if bl.NumTX != 4 || len(bl.TXids) != 2 || bl.Height != height {
+		t.Fatalf("GetBlock returned %d of %d txids at height %d", len(bl.TXids), bl.NumTX, bl.Height)
+	}
+	bl2, err := bc.GetBlockNextTXs(bl)
+	if err != nil {
+		t.Fatal("GetBlockNextTXs error encountered: ", err)
+	}
+	if len(bl2.TXids) != 2 || bl2.TXids[0] == bl.TXids[0] {
+		t.Errorf("GetBlockNextTXs returned %v after %v", bl2.TXids, bl.TXids)
+	}
+	if _, err = bc.GetBlock(height+1, "", nil); !gobcy.IsNotFound(err) {
+		t.Error("Expected not found error for a missing block, got: ", err)
+	}
+	if _, err = bc.GetBlock(height, ch.Hash, nil); err == nil {
+		t.Error("Expected error when sending both height and hash to GetBlock")
+	}
+	gen, err := bc.GetBlock(0, "", nil)
+	if err != nil {
+		t.Fatal("GetBlock of genesis error encountered: ", err)
+	}
+	if gen.Height != 0 || gen.Hash == "" {
+		t.Errorf("GetBlock(0) returned %s at height %d", gen.Hash, gen.Height)
+	}
+	byHash, err := bc.GetBlockByID(gobcy.ByHash(gen.Hash), nil)
+	if err != nil {
+		t.Fatal("GetBlockByID error encountered: ", err)
+	}
+	if byHash.Height != 0 {
+		t.Errorf("GetBlockByID(ByHash) of genesis returned height %d", byHash.Height)
+	}
+	hdr, err := bc.GetBlockHeader(gobcy.ByHeight(height))
+	if err != nil {
+		t.Fatal("GetBlockHeader error encountered: ", err)
+	}
+	if hdr.Hash != ch.Hash || hdr.NumTX != 4 || len(hdr.TXids) != 0 || hdr.NextTXs != "" {
+		t.Errorf("GetBlockHeader returned %s with %d txids and NextTXs %q", hdr.Hash, len(hdr.TXids), hdr.NextTXs)
+	}
+}
+
+func TestServerWallet(t *testing.T) {
+	srv := NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	keys1 := fundedKeys(t, srv, bc, 1e5)
+	keys2 := fundedKeys(t, srv, bc, 2e5)
+	if _, err := bc.CreateWallet(gobcy.Wallet{Name: "testwallet", Addresses: []string{keys1.Address}}); err != nil {
+		t.Fatal("CreateWallet error encountered: ", err)
+	}
+	if _, err := bc.AddAddrWallet("testwallet", []string{keys2.Address}, false); err != nil {
+		t.Fatal("AddAddrWallet error encountered: ", err)
+	}
+	addr, err := bc.GetAddrBal("testwallet", nil)
+	if err != nil {
+		t.Fatal("GetAddrBal error encountered: ", err)
+	}
+	if addr.Balance.Int64() != 3e5 || addr.Wallet.Name != "testwallet" {
+		t.Errorf("GetAddrBal of a wallet returned balance %v", &addr.Balance)
+	}
+	if err = bc.DeleteAddrWallet("testwallet", []string{keys1.Address}); err != nil {
+		t.Fatal("DeleteAddrWallet error encountered: ", err)
+	}
+	addrs, err := bc.GetAddrWallet("testwallet", nil)
+	if err != nil {
+		t.Fatal("GetAddrWallet error encountered: ", err)
+	}
+	if len(addrs) != 1 || addrs[0] != keys2.Address {
+		t.Error("GetAddrWallet response does not match expected addresses")
+	}
+	wal, newKeys, err := bc.GenAddrWallet("testwallet")
+	if err != nil {
+		t.Fatal("GenAddrWallet error encountered: ", err)
+	}
+	if len(wal.Addresses) != 2 || wal.Addresses[1] != newKeys.Address || newKeys.Private == "" {
+		t.Errorf("GenAddrWallet returned %+v and %+v", wal, newKeys)
+	}
+	if _, err = bc.CreateWallet(gobcy.Wallet{Name: "testwallet"}); err == nil {
+		t.Error("Expected error creating a wallet twice, did not receive one")
+	}
+	if err = bc.DeleteWallet("testwallet"); err != nil {
+		t.Fatal("DeleteWallet error encountered: ", err)
+	}
+	if list, err := bc.ListWallets(); err != nil || len(list) != 0 {
+		t.Errorf("ListWallets returned %v, %v; expected none", list, err)
+	}
+}
+
+func TestServerHDWallet(t *testing.T) {
+	srv := NewServer("btc", "main")
+	defer srv.Close()
+	bc := srv.API("token")
+	master, err := hdkeychain.NewMaster(bytes.Repeat([]byte{1}, 32), &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xpub, err := master.Neuter()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = bc.CreateHDWallet(gobcy.HDWallet{Name: "testhdwallet", ExtPubKey: xpub.String(), SubchainIndexes: []int{0, 1}})
+	if err != nil {
+		t.Fatal("CreateHDWallet error encountered: ", err)
+	}
+	wal, err := bc.DeriveAddrHDWallet("testhdwallet", gobcy.DeriveQuery{Count: 2, SubchainIndex: new(int)}.Params())
+	if err != nil {
+		t.Fatal("DeriveAddrHDWallet error encountered: ", err)
+	}
+	if len(wal.Chains) != 1 || len(wal.Chains[0].ChainAddr) != 2 {
+		t.Fatalf("DeriveAddrHDWallet returned %+v", wal)
+	}
+	//check the second address against a local derivation
+	key, _ := xpub.Derive(0)
+	key, _ = key.Derive(1)
+	pub, _ := key.ECPubKey()
+	want, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pub.SerializeCompressed()), &chaincfg.MainNetParams)
+	if got := wal.Chains[0].ChainAddr[1]; got.Address != want.EncodeAddress() || got.Path != "m/0/1" {
+		t.Errorf("DeriveAddrHDWallet derived %s at %s, expected %s at m/0/1", got.Address, got.Path, want)
+	}
+	srv.Fund(want.EncodeAddress(), 5000)
+	addr, err := bc.GetAddrBal("testhdwallet", nil)
+	if err != nil {
+		t.Fatal("GetAddrBal error encountered: ", err)
+	}
+	if addr.FinalBalance.Int64() != 5000 {
+		t.Errorf("GetAddrBal of an HD wallet returned final balance %v", &addr.FinalBalance)
+	}
+	if err = bc.DeleteHDWallet("testhdwallet"); err != nil {
+		t.Fatal("DeleteHDWallet error encountered: ", err)
+	}
+	if _, err = bc.GetHDWallet("testhdwallet"); !gobcy.IsNotFound(err) {
+		t.Error("Expected not found error for a deleted HD wallet, got: ", err)
+	}
+}
+
+func TestServerHookPayFwdMeta(t *testing.T) {
+	srv := NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	keys := fundedKeys(t, srv, bc, 1e5)
+	hook, err := bc.CreateHook(gobcy.Hook{Event: "new-block", URL: "https://my.domain.com/callback"})
+	if err != nil {
+		t.Fatal("CreateHook error encountered: ", err)
+	}
+	if _, err = bc.CreateHook(gobcy.Hook{Event: "new-thing", URL: "https://my.domain.com/callback"}); err == nil {
+		t.Error("Expected error creating a hook with an invalid event, did not receive one")
+	}
+	if got, err := bc.GetHook(hook.ID); err != nil || got.URL != hook.URL {
+		t.Errorf("GetHook returned %+v, %v", got, err)
+	}
+	if err = bc.DeleteHook(hook.ID); err != nil {
+		t.Fatal("DeleteHook error encountered: ", err)
+	}
+	if hooks, err := bc.ListHooks(); err != nil || len(hooks) != 0 {
+		t.Errorf("ListHooks returned %v, %v; expected none", hooks, err)
+	}
+	pay, err := bc.CreatePayFwd(gobcy.PayFwd{Destination: keys.Address})
+	if err != nil {
+		t.Fatal("CreatePayFwd error encountered: ", err)
+	}
+	if pay.ID == "" || pay.InputAddr == "" {
+		t.Errorf("CreatePayFwd returned %+v", pay)
+	}
+	if pays, err := bc.ListPayFwdsPage(1); err != nil || len(pays) != 0 {
+		t.Errorf("ListPayFwdsPage returned %v, %v; expected none", pays, err)
+	}
+	if err = bc.DeletePayFwd(pay.ID); err != nil {
+		t.Fatal("DeletePayFwd error encountered: ", err)
+	}
+	if err = bc.PutMeta(keys.Address, "addr", true, map[string]string{"key": "value"}); err != nil {
+		t.Fatal("PutMeta error encountered: ", err)
+	}
+	if data, err := bc.GetMeta(keys.Address, "addr", true); err != nil || data["key"] != "value" {
+		t.Errorf("GetMeta returned %v, %v", data, err)
+	}
+	if data, err := srv.API("other").GetMeta(keys.Address, "addr", true); err != nil || len(data) != 0 {
+		t.Errorf("GetMeta with another token returned %v, %v", data, err)
+	}
+	if err = bc.DeleteMeta(keys.Address, "addr"); err != nil {
+		t.Fatal("DeleteMeta error encountered: ", err)
+	}
+	if err = bc.PutMeta("1", "block", false, map[string]string{"key": "value"}); err != nil {
+		t.Fatal("PutMeta error encountered: ", err)
+	}
+	if err = bc.PutMeta("1", "block", false, map[string]string{"key": "other"}); err == nil {
+		t.Error("Expected error changing public metadata, did not receive one")
+	}
+	usage, err := bc.CheckUsage()
+	if err != nil {
+		t.Fatal("CheckUsage error encountered: ", err)
+	}
+	if usage.Hits.PerHour == 0 || usage.Hits.HooksPerHour != 2 || usage.Hits.PayFwds != 0 {
+		t.Errorf("CheckUsage returned %+v", usage.Hits)
+	}
+}
+
+func TestServerAsset(t *testing.T) {
+	srv := NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	oap1, err := bc.GenAssetKeychain()
+	if err != nil {
+		t.Fatal("GenAssetKeychain error encountered: ", err)
+	}
+	oap2, err := bc.GenAssetKeychain()
+	if err != nil {
+		t.Fatal("GenAssetKeychain error encountered: ", err)
+	}
+	funder := fundedKeys(t, srv, bc, 1e6)
+	tx1, err := bc.IssueAsset(gobcy.OAPIssue{Priv: funder.Private, ToAddr: oap1.OAPAddress, Amount: *big.NewInt(9000)})
+	if err != nil {
+		t.Fatal("IssueAsset error encountered: ", err)
+	}
+	srv.Mine()
+	if conf, err := bc.GetTXConf(tx1.Hash); err != nil || conf.Confidence != 1 {
+		t.Errorf("GetTXConf of the issuance returned %+v, %v", conf, err)
+	}
+	if _, err = bc.TransferAsset(gobcy.OAPIssue{Priv: oap1.Private, ToAddr: oap2.OAPAddress, Amount: *big.NewInt(8999)}, tx1.AssetID); err != nil {
+		t.Fatal("TransferAsset error encountered: ", err)
+	}
+	if _, err = bc.TransferAsset(gobcy.OAPIssue{Priv: oap1.Private, ToAddr: oap2.OAPAddress, Amount: *big.NewInt(2)}, tx1.AssetID); err == nil {
+		t.Error("Expected error transferring more than the balance, did not receive one")
+	}
+	txs, err := bc.ListAssetTXs(tx1.AssetID)
+	if err != nil || len(txs) != 2 {
+		t.Errorf("ListAssetTXs returned %v, %v", txs, err)
+	}
+	checktx, err := bc.GetAssetTX(tx1.AssetID, tx1.Hash)
+	if err != nil || checktx.Confirmed.IsZero() {
+		t.Errorf("GetAssetTX returned %+v, %v", checktx, err)
+	}
+	for addr, want := range map[string]int64{oap1.OAPAddress: 1, oap2.OAPAddress: 8999} {
+		got, err := bc.GetAssetAddr(tx1.AssetID, addr)
+		if err != nil || got.Balance.Int64() != want {
+			t.Errorf("GetAssetAddr returned %v, %v; expected %d", &got.Balance, err, want)
+		}
+	}
+}
+
+//TestPreimages cross-checks the signing preimages
+//against btcd's signature hashes.
+func TestPreimages(t *testing.T) {
+	tx := wire.NewMsgTx(2)
+	for i := 0; i < 3; i++ {
+		tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{byte(i)}, uint32(i)), nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+	tx.AddTxOut(wire.NewTxOut(2000, p2pkhCode(make([]byte, 20))))
+	tx.LockTime = 7
+	code := p2pkhCode(bytes.Repeat([]byte{9}, 20))
+	for i := range tx.TxIn {
+		want, err := txscript.CalcSignatureHash(code, txscript.SigHashAll, tx, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := chainhash.DoubleHashB(legacyPreimage(tx, i, code)); !bytes.Equal(got, want) {
+			t.Errorf("legacy preimage of input %d hashes to %x, expected %x", i, got, want)
+		}
+		prevOuts := txscript.NewCannedPrevOutputFetcher(code, 5000)
+		want, err = txscript.CalcWitnessSigHash(code, txscript.NewTxSigHashes(tx, prevOuts), txscript.SigHashAll, tx, i, 5000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := chainhash.DoubleHashB(witnessPreimage(tx, i, code, 5000)); !bytes.Equal(got, want) {
+			t.Errorf("witness preimage of input %d hashes to %x, expected %x", i, got, want)
+		}
+	}
+}
+
+//multisigAddr returns the P2SH address of an n-of-len(pubkeys) multisig.
+func multisigAddr(t *testing.T, pubkeys []string, n int, params *chaincfg.Params) string {
+	var keys []*btcutil.AddressPubKey
+	for _, k := range pubkeys {
+		pub, _ := hex.DecodeString(k)
+		key, err := btcutil.NewAddressPubKey(pub, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	redeem, _ := txscript.MultiSigScript(keys, n)
+	addr, _ := btcutil.NewAddressScriptHash(redeem, params)
+	return addr.EncodeAddress()
+}
@@ -0,0 +1,180 @@
+package gobcytest
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+)
+
+//hdWallet is an HD wallet, along with the
+//addresses derived from it so far.
+type hdWallet struct {
+	name      string
+	xpub      string
+	key       *hdkeychain.ExtendedKey
+	subchains []int
+	//derived addresses by subchain index,
+	//with -1 for a wallet without subchains
+	derived map[int][]hdAddr
+}
+
+//hdAddr is a derived address, with the same JSON
+//as an element of gobcy.HDWallet's chain addresses.
+type hdAddr struct {
+	Address string `json:"address,omitempty"`
+	Path    string `json:"path,omitempty"`
+	Public  string `json:"public,omitempty"`
+}
+
+//hdChain is the JSON of an element of gobcy.HDWallet's chains.
+type hdChain struct {
+	ChainAddr []hdAddr `json:"chain_addresses,omitempty"`
+	Index     int      `json:"index,omitempty"`
+}
+
+//hdView is the JSON of a gobcy.HDWallet.
+type hdView struct {
+	Name            string    `json:"name,omitempty"`
+	ExtPubKey       string    `json:"extended_public_key,omitempty"`
+	SubchainIndexes []int     `json:"subchain_indexes,omitempty"`
+	Chains          []hdChain `json:"chains,omitempty"`
+}
+
+//view returns hd with the given derived addresses, by subchain.
+func (hd *hdWallet) view(chains map[int][]hdAddr) hdView {
+	v := hdView{Name: hd.name, ExtPubKey: hd.xpub, SubchainIndexes: hd.subchains}
+	for _, sub := range hd.chainIndexes() {
+		if addrs, found := chains[sub]; found {
+			c := hdChain{ChainAddr: addrs}
+			if sub >= 0 {
+				c.Index = sub
+			}
+			v.Chains = append(v.Chains, c)
+		}
+	}
+	return v
+}
+
+//chainIndexes returns the subchain indexes of hd,
+//or just -1 if it has none.
+func (hd *hdWallet) chainIndexes() []int {
+	if len(hd.subchains) == 0 {
+		return []int{-1}
+	}
+	return hd.subchains
+}
+
+//addresses returns every address derived from hd.
+func (hd *hdWallet) addresses() (addrs []string) {
+	for _, sub := range hd.chainIndexes() {
+		for _, a := range hd.derived[sub] {
+			addrs = append(addrs, a.Address)
+		}
+	}
+	return
+}
+
+//derive derives the next address of subchain sub.
+func (s *Server) derive(hd *hdWallet, sub int) (a hdAddr, err error) {
+	key, path := hd.key, "m"
+	if sub >= 0 {
+		if key, err = key.Derive(uint32(sub)); err != nil {
+			return
+		}
+		path += "/" + strconv.Itoa(sub)
+	}
+	i := len(hd.derived[sub])
+	if key, err = key.Derive(uint32(i)); err != nil {
+		return
+	}
+	pub, err := key.ECPubKey()
+	if err != nil {
+		return
+	}
+	raw := pub.SerializeCompressed()
+	addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(raw), s.params)
+	if err != nil {
+		return
+	}
+	a = hdAddr{Address: addr.EncodeAddress(), Path: path + "/" + strconv.Itoa(i), Public: hex.EncodeToString(raw)}
+	hd.derived[sub] = append(hd.derived[sub], a)
+	return
+}
+
+func (s *Server) routeHDWallets(r *http.Request, segs []string, q url.Values) result {
+	if len(segs) == 0 && r.Method == "POST" {
+		var req gobcy.HDWallet
+		if err := decode(r, &req); err != nil {
+			return fail(http.StatusBadRequest, "%v", err)
+		}
+		if req.Name == "" {
+			return fail(http.StatusBadRequest, "A wallet needs a name")
+		}
+		if s.walletExists(req.Name) {
+			return fail(http.StatusConflict, "Wallet %s already exists", req.Name)
+		}
+		key, err := hdkeychain.NewKeyFromString(req.ExtPubKey)
+		if err != nil {
+			return fail(http.StatusBadRequest, "Invalid extended public key: %v", err)
+		}
+		key, err = key.Neuter()
+		if err != nil {
+			return fail(http.StatusBadRequest, "Invalid extended public key: %v", err)
+		}
+		hd := &hdWallet{name: req.Name, xpub: req.ExtPubKey, key: key, subchains: req.SubchainIndexes, derived: make(map[int][]hdAddr)}
+		s.hdwallets[hd.name] = hd
+		return ok(http.StatusCreated, hd.view(nil))
+	}
+	if len(segs) == 0 {
+		return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+	}
+	hd, found := s.hdwallets[segs[0]]
+	if !found {
+		return fail(http.StatusNotFound, "Wallet %s not found.", segs[0])
+	}
+	switch {
+	case len(segs) == 1 && r.Method == "GET":
+		return ok(http.StatusOK, hd.view(nil))
+	case len(segs) == 1 && r.Method == "DELETE":
+		delete(s.hdwallets, hd.name)
+		return ok(http.StatusNoContent, nil)
+	case len(segs) == 2 && segs[1] == "addresses" && r.Method == "GET":
+		return ok(http.StatusOK, hd.view(hd.derived))
+	case len(segs) == 3 && segs[1] == "addresses" && segs[2] == "derive" && r.Method == "POST":
+		sub := -1
+		if len(hd.subchains) > 0 {
+			sub = intParam(q, "subchain_index", hd.subchains[0])
+			if !containsInt(hd.subchains, sub) {
+				return fail(http.StatusBadRequest, "Wallet %s has no subchain %d", hd.name, sub)
+			}
+		}
+		count := intParam(q, "count", 1)
+		if count < 1 {
+			return fail(http.StatusBadRequest, "Count must be positive")
+		}
+		chains := make(map[int][]hdAddr)
+		for i := 0; i < count; i++ {
+			a, err := s.derive(hd, sub)
+			if err != nil {
+				return fail(http.StatusInternalServerError, "%v", err)
+			}
+			chains[sub] = append(chains[sub], a)
+		}
+		return ok(http.StatusOK, hd.view(chains))
+	}
+	return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
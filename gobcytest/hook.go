@@ -0,0 +1,56 @@
+package gobcytest
+
+import (
+	"net/http"
+
+	"github.com/blockcypher/gobcy/v2"
+)
+
+//hookEvents are the events a Hook can subscribe to.
+var hookEvents = map[string]bool{
+	"unconfirmed-tx":  true,
+	"new-block":       true,
+	"confirmed-tx":    true,
+	"tx-confirmation": true,
+	"double-spend-tx": true,
+	"tx-confidence":   true,
+}
+
+func (s *Server) routeHooks(r *http.Request, segs []string) result {
+	switch {
+	case len(segs) == 0 && r.Method == "POST":
+		var hook gobcy.Hook
+		if err := decode(r, &hook); err != nil {
+			return fail(http.StatusBadRequest, "%v", err)
+		}
+		if !hookEvents[hook.Event] {
+			return fail(http.StatusBadRequest, "Invalid event %q", hook.Event)
+		}
+		if hook.URL == "" {
+			return fail(http.StatusBadRequest, "A hook needs a url")
+		}
+		if len(s.hooks) >= s.limits.Hooks {
+			return fail(http.StatusTooManyRequests, "Limit of %d hooks reached", s.limits.Hooks)
+		}
+		hook.ID = s.newID("hook-")
+		s.hooks = append(s.hooks, hook)
+		return ok(http.StatusCreated, hook)
+	case len(segs) == 0 && r.Method == "GET":
+		return ok(http.StatusOK, append([]gobcy.Hook{}, s.hooks...))
+	case len(segs) == 1:
+		for i, hook := range s.hooks {
+			if hook.ID != segs[0] {
+				continue
+			}
+			switch r.Method {
+			case "GET":
+				return ok(http.StatusOK, hook)
+			case "DELETE":
+				s.hooks = append(s.hooks[:i], s.hooks[i+1:]...)
+				return ok(http.StatusNoContent, nil)
+			}
+		}
+		return fail(http.StatusNotFound, "Hook %s not found.", segs[0])
+	}
+	return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+}
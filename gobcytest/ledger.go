@@ -0,0 +1,492 @@
+package gobcytest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+//entry is a transaction in the ledger.
+type entry struct {
+	msg      *wire.MsgTx
+	hash     string
+	height   int
+	block    string
+	received time.Time
+	pref     string
+	spentBy  []string
+	//replacedBy is the transaction that replaced an
+	//unconfirmed one, and replaces the one it replaced
+	replacedBy string
+	replaces   string
+}
+
+//block is a block in the ledger.
+type block struct {
+	header   wire.BlockHeader
+	hash     string
+	height   int
+	received time.Time
+	txids    []string
+}
+
+//genesisTime is the timestamp of the genesis block;
+//later blocks are ten minutes apart.
+var genesisTime = time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+
+//Fund adds an unconfirmed transaction paying amount to
+//address, like a coinbase, and returns its hash. Call
+//Mine to confirm it.
+func (s *Server) Fund(address string, amount int64) (txhash string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fund(address, amount)
+}
+
+func (s *Server) fund(address string, amount int64) (txhash string, err error) {
+	pkScript, err := s.pkScript(address)
+	if err != nil {
+		return
+	}
+	s.seq++
+	tx := coinbase(s.seq)
+	tx.AddTxOut(wire.NewTxOut(amount, pkScript))
+	e, err := s.insert(tx, "high")
+	if err != nil {
+		return
+	}
+	txhash = e.hash
+	return
+}
+
+//Mine confirms all unconfirmed transactions in a new block
+//on top of the chain, and returns its height.
+func (s *Server) Mine() (height int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mine().height
+}
+
+//Reorg orphans the top depth blocks of the chain, returning
+//their transactions to the mempool, and returns the height of
+//the new tip. Mine new blocks to replace them; the chain then
+//reports the fork through its LastForkHeight and LastForkHash.
+//The genesis block is never orphaned.
+func (s *Server) Reorg(depth int) (height int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if depth > len(s.blocks)-1 {
+		depth = len(s.blocks) - 1
+	}
+	if depth <= 0 {
+		return s.tip().height
+	}
+	orphaned := s.blocks[len(s.blocks)-depth:]
+	s.blocks = s.blocks[:len(s.blocks)-depth]
+	var mempool []string
+	for _, b := range orphaned {
+		for i, id := range b.txids {
+			//the block's own coinbase goes with it
+			if i == 0 {
+				delete(s.txs, id)
+				continue
+			}
+			e := s.txs[id]
+			e.height, e.block = -1, ""
+			mempool = append(mempool, id)
+		}
+	}
+	s.mempool = append(mempool, s.mempool...)
+	s.forkHeight, s.forkHash = orphaned[0].height, orphaned[0].hash
+	return s.tip().height
+}
+
+//Height returns the height of the chain's tip.
+func (s *Server) Height() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tip().height
+}
+
+//tip returns the last block of the chain.
+func (s *Server) tip() *block {
+	return s.blocks[len(s.blocks)-1]
+}
+
+//coinbase returns a transaction without outputs, whose
+//only input is a coinbase input unique to n.
+func coinbase(n int) *wire.MsgTx {
+	tx := wire.NewMsgTx(1)
+	script := []byte("gobcytest/" + strconv.Itoa(n))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex), script, nil))
+	return tx
+}
+
+func (s *Server) mine() *block {
+	height := len(s.blocks)
+	s.seq++
+	cb := coinbase(s.seq)
+	cb.AddTxOut(wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	cbe := &entry{msg: cb, hash: cb.TxHash().String(), received: time.Now(), pref: "high", spentBy: make([]string, 1)}
+	s.txs[cbe.hash] = cbe
+	txids := append([]string{cbe.hash}, s.mempool...)
+	s.mempool = nil
+	b := &block{height: height, received: time.Now(), txids: txids}
+	b.header = wire.BlockHeader{
+		Version:    1,
+		MerkleRoot: merkleRoot(txids),
+		Timestamp:  genesisTime.Add(time.Duration(height) * 10 * time.Minute),
+		Bits:       0x1d00ffff,
+		Nonce:      uint32(s.seq),
+	}
+	if height > 0 {
+		prev, _ := chainhash.NewHashFromStr(s.tip().hash)
+		b.header.PrevBlock = *prev
+	}
+	b.hash = b.header.BlockHash().String()
+	for _, id := range txids {
+		e := s.txs[id]
+		e.height = height
+		e.block = b.hash
+	}
+	s.blocks = append(s.blocks, b)
+	return b
+}
+
+//merkleRoot computes the merkle root of txids.
+func merkleRoot(txids []string) chainhash.Hash {
+	level := make([]chainhash.Hash, len(txids))
+	for i, id := range txids {
+		h, _ := chainhash.NewHashFromStr(id)
+		level[i] = *h
+	}
+	for len(level) > 1 {
+		if len(level)%2 == 1 {
+			level = append(level, level[len(level)-1])
+		}
+		var next []chainhash.Hash
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, chainhash.DoubleHashH(append(level[i][:], level[i+1][:]...)))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+//insert validates tx against the ledger and adds it
+//as unconfirmed, marking the outputs it spends.
+func (s *Server) insert(tx *wire.MsgTx, pref string) (e *entry, err error) {
+	hash := tx.TxHash().String()
+	if _, found := s.txs[hash]; found {
+		err = errors.New("Transaction " + hash + " already exists")
+		return
+	}
+	var replaced []*entry
+	if !isCoinbase(tx) {
+		if err = s.verify(tx); err != nil {
+			return
+		}
+		if replaced, err = s.replaced(tx); err != nil {
+			return
+		}
+	}
+	e = &entry{msg: tx, hash: hash, height: -1, received: time.Now(), pref: pref, spentBy: make([]string, len(tx.TxOut))}
+	for _, r := range replaced {
+		s.evict(r, hash)
+		e.replaces = r.hash
+	}
+	if !isCoinbase(tx) {
+		for _, in := range tx.TxIn {
+			prev := s.txs[in.PreviousOutPoint.Hash.String()]
+			prev.spentBy[in.PreviousOutPoint.Index] = hash
+		}
+	}
+	s.txs[hash] = e
+	s.mempool = append(s.mempool, hash)
+	return
+}
+
+func isCoinbase(tx *wire.MsgTx) bool {
+	return len(tx.TxIn) == 1 && tx.TxIn[0].PreviousOutPoint.Index == wire.MaxPrevOutIndex &&
+		tx.TxIn[0].PreviousOutPoint.Hash == chainhash.Hash{}
+}
+
+//prevOut returns the output spent by in, or an error if it's
+//unknown or already spent by a transaction it can't replace.
+func (s *Server) prevOut(op wire.OutPoint) (out *wire.TxOut, err error) {
+	prev, found := s.txs[op.Hash.String()]
+	if !found || int(op.Index) >= len(prev.msg.TxOut) {
+		err = errors.New("Output " + op.String() + " not found")
+		return
+	}
+	if by := prev.spentBy[op.Index]; by != "" && !s.replaceable(s.txs[by]) {
+		err = errors.New("Output " + op.String() + " already spent by " + by)
+		return
+	}
+	out = prev.msg.TxOut[op.Index]
+	return
+}
+
+//replaceable returns whether e is unconfirmed and signals
+//replaceability (BIP-125) through one of its sequences.
+func (s *Server) replaceable(e *entry) bool {
+	if e.height >= 0 {
+		return false
+	}
+	for _, in := range e.msg.TxIn {
+		if in.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+	return false
+}
+
+//replaced returns the unconfirmed transactions tx double-spends,
+//which it replaces if it pays a higher fee rate than each, and
+//their fees plus 1 satoshi per virtual byte of its own (BIP-125).
+func (s *Server) replaced(tx *wire.MsgTx) (es []*entry, err error) {
+	seen := make(map[string]bool)
+	for _, in := range tx.TxIn {
+		by := s.txs[in.PreviousOutPoint.Hash.String()].spentBy[in.PreviousOutPoint.Index]
+		if by != "" && !seen[by] {
+			seen[by] = true
+			es = append(es, s.txs[by])
+		}
+	}
+	fee, min := s.fee(&entry{msg: tx}), int64(vsize(tx))
+	for _, e := range es {
+		old := s.fee(e)
+		min += old
+		if fee*int64(vsize(e.msg)) <= old*int64(vsize(tx)) || fee < min {
+			return nil, errors.New("Insufficient fee to replace " + e.hash)
+		}
+	}
+	return
+}
+
+//evict removes the unconfirmed e, and the transactions
+//spending its outputs, from the mempool, as replaced by
+//the transaction by.
+func (s *Server) evict(e *entry, by string) {
+	e.replacedBy = by
+	for i, id := range s.mempool {
+		if id == e.hash {
+			s.mempool = append(s.mempool[:i], s.mempool[i+1:]...)
+			break
+		}
+	}
+	for _, in := range e.msg.TxIn {
+		prev := s.txs[in.PreviousOutPoint.Hash.String()]
+		if prev.spentBy[in.PreviousOutPoint.Index] == e.hash {
+			prev.spentBy[in.PreviousOutPoint.Index] = ""
+		}
+	}
+	for _, id := range e.spentBy {
+		if id != "" && s.txs[id].replacedBy == "" {
+			s.evict(s.txs[id], by)
+		}
+	}
+}
+
+//verify checks that every input of tx spends an unspent
+//output with a valid script, and that it doesn't create
+//more value than it spends.
+func (s *Server) verify(tx *wire.MsgTx) error {
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	var in, out int64
+	for _, txIn := range tx.TxIn {
+		prev, err := s.prevOut(txIn.PreviousOutPoint)
+		if err != nil {
+			return err
+		}
+		prevOuts[txIn.PreviousOutPoint] = prev
+		in += prev.Value
+	}
+	for _, txOut := range tx.TxOut {
+		out += txOut.Value
+	}
+	if out > in {
+		return errors.New("Outputs exceed inputs")
+	}
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	hashes := txscript.NewTxSigHashes(tx, fetcher)
+	for i, txIn := range tx.TxIn {
+		prev := prevOuts[txIn.PreviousOutPoint]
+		vm, err := txscript.NewEngine(prev.PkScript, tx, i, txscript.StandardVerifyFlags, nil, hashes, prev.Value, fetcher)
+		if err == nil {
+			err = vm.Execute()
+		}
+		if err != nil {
+			return errors.New("Error validating input " + strconv.Itoa(i) + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+//pkScript returns the output script paying to address.
+func (s *Server) pkScript(address string) ([]byte, error) {
+	addr, err := btcutil.DecodeAddress(address, s.params)
+	if err != nil || !addr.IsForNet(s.params) {
+		return nil, errors.New("Address " + address + " is invalid for " + s.Coin + "/" + s.Chain)
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+//scriptInfo returns the addresses paid by pkScript
+//and its BlockCypher script type.
+func (s *Server) scriptInfo(pkScript []byte) (addrs []string, scriptType string) {
+	class, as, _, _ := txscript.ExtractPkScriptAddrs(pkScript, s.params)
+	for _, a := range as {
+		addrs = append(addrs, a.EncodeAddress())
+	}
+	switch class {
+	case txscript.PubKeyHashTy:
+		scriptType = "pay-to-pubkey-hash"
+	case txscript.ScriptHashTy:
+		scriptType = "pay-to-script-hash"
+	case txscript.WitnessV0PubKeyHashTy:
+		scriptType = "pay-to-witness-pubkey-hash"
+	case txscript.WitnessV0ScriptHashTy:
+		scriptType = "pay-to-witness-script-hash"
+	case txscript.PubKeyTy:
+		scriptType = "pay-to-pubkey"
+	case txscript.MultiSigTy:
+		scriptType = "pay-to-multi-pubkey-hash"
+	case txscript.NullDataTy:
+		scriptType = "null-data"
+	default:
+		scriptType = "unknown"
+	}
+	return
+}
+
+//confirmations returns how many blocks confirm e.
+func (s *Server) confirmations(e *entry) int {
+	if e.height < 0 {
+		return 0
+	}
+	return s.tip().height - e.height + 1
+}
+
+//ordered returns every transaction, newest first: unconfirmed
+//ones by time received, then confirmed ones by height and
+//position within their block.
+func (s *Server) ordered() (es []*entry) {
+	for i := len(s.mempool) - 1; i >= 0; i-- {
+		es = append(es, s.txs[s.mempool[i]])
+	}
+	for h := len(s.blocks) - 1; h >= 0; h-- {
+		ids := s.blocks[h].txids
+		for i := len(ids) - 1; i >= 0; i-- {
+			es = append(es, s.txs[ids[i]])
+		}
+	}
+	return
+}
+
+//lookupBlock finds a block by height or hash.
+func (s *Server) lookupBlock(id string) *block {
+	if h, err := strconv.Atoi(id); err == nil && len(id) < 64 {
+		if h >= 0 && h < len(s.blocks) {
+			return s.blocks[h]
+		}
+		return nil
+	}
+	for _, b := range s.blocks {
+		if b.hash == id {
+			return b
+		}
+	}
+	return nil
+}
+
+//serialize returns the raw bytes of tx, with witness data.
+func serialize(tx *wire.MsgTx) []byte {
+	var buf bytes.Buffer
+	tx.Serialize(&buf)
+	return buf.Bytes()
+}
+
+//vsize returns the virtual size of tx.
+func vsize(tx *wire.MsgTx) int {
+	weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+	return (weight + 3) / 4
+}
+
+//parseTX decodes a hex-encoded raw transaction.
+func parseTX(rawHex string) (*wire.MsgTx, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(rawHex))
+	if err != nil {
+		return nil, errors.New("Invalid hex: " + err.Error())
+	}
+	tx := wire.NewMsgTx(1)
+	if err = tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return nil, errors.New("Invalid transaction: " + err.Error())
+	}
+	return tx, nil
+}
+
+//legacyPreimage returns the data hashed to sign input idx of
+//tx with SIGHASH_ALL, before segwit, using scriptCode as the
+//input's script.
+func legacyPreimage(tx *wire.MsgTx, idx int, scriptCode []byte) []byte {
+	cp := tx.Copy()
+	for i, in := range cp.TxIn {
+		in.Witness = nil
+		in.SignatureScript = nil
+		if i == idx {
+			in.SignatureScript = scriptCode
+		}
+	}
+	var buf bytes.Buffer
+	cp.SerializeNoWitness(&buf)
+	binary.Write(&buf, binary.LittleEndian, uint32(txscript.SigHashAll))
+	return buf.Bytes()
+}
+
+//witnessPreimage returns the BIP-143 data hashed to sign input
+//idx of tx with SIGHASH_ALL, spending amount with scriptCode.
+func witnessPreimage(tx *wire.MsgTx, idx int, scriptCode []byte, amount int64) []byte {
+	var prevouts, seqs, outs bytes.Buffer
+	for _, in := range tx.TxIn {
+		prevouts.Write(in.PreviousOutPoint.Hash[:])
+		binary.Write(&prevouts, binary.LittleEndian, in.PreviousOutPoint.Index)
+		binary.Write(&seqs, binary.LittleEndian, in.Sequence)
+	}
+	for _, out := range tx.TxOut {
+		wire.WriteTxOut(&outs, 0, 0, out)
+	}
+	in := tx.TxIn[idx]
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, uint32(tx.Version))
+	buf.Write(chainhash.DoubleHashB(prevouts.Bytes()))
+	buf.Write(chainhash.DoubleHashB(seqs.Bytes()))
+	buf.Write(in.PreviousOutPoint.Hash[:])
+	binary.Write(&buf, binary.LittleEndian, in.PreviousOutPoint.Index)
+	wire.WriteVarBytes(&buf, 0, scriptCode)
+	binary.Write(&buf, binary.LittleEndian, amount)
+	binary.Write(&buf, binary.LittleEndian, in.Sequence)
+	buf.Write(chainhash.DoubleHashB(outs.Bytes()))
+	binary.Write(&buf, binary.LittleEndian, tx.LockTime)
+	binary.Write(&buf, binary.LittleEndian, uint32(txscript.SigHashAll))
+	return buf.Bytes()
+}
+
+//sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]bool) (keys []string) {
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return
+}
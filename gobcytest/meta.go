@@ -0,0 +1,60 @@
+package gobcytest
+
+import (
+	"net/http"
+	"net/url"
+)
+
+//routeMeta serves the metadata of an addr, tx or block.
+//Public metadata is shared and immutable; private metadata
+//is kept per token.
+func (s *Server) routeMeta(r *http.Request, kind, id string, q url.Values) result {
+	switch kind {
+	case "addr":
+		if _, err := s.pkScript(id); err != nil {
+			return fail(http.StatusBadRequest, "%v", err)
+		}
+	case "tx":
+		if _, found := s.txs[id]; !found {
+			return fail(http.StatusNotFound, "Transaction %s not found.", id)
+		}
+	case "block":
+		b := s.lookupBlock(id)
+		if b == nil {
+			return fail(http.StatusNotFound, "Block %s not found.", id)
+		}
+		id = b.hash
+	}
+	private := q.Get("private") == "true" || r.Method == "DELETE"
+	key := kind + "/" + id + "/"
+	if private {
+		key += q.Get("token")
+	}
+	switch r.Method {
+	case "GET":
+		meta := make(map[string]string)
+		for k, v := range s.meta[key] {
+			meta[k] = v
+		}
+		return ok(http.StatusOK, meta)
+	case "PUT":
+		var meta map[string]string
+		if err := decode(r, &meta); err != nil {
+			return fail(http.StatusBadRequest, "%v", err)
+		}
+		if !private && len(s.meta[key]) > 0 {
+			return fail(http.StatusConflict, "Public metadata is immutable")
+		}
+		if s.meta[key] == nil {
+			s.meta[key] = make(map[string]string)
+		}
+		for k, v := range meta {
+			s.meta[key][k] = v
+		}
+		return ok(http.StatusNoContent, nil)
+	case "DELETE":
+		delete(s.meta, key)
+		return ok(http.StatusNoContent, nil)
+	}
+	return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+}
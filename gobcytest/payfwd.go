@@ -0,0 +1,57 @@
+package gobcytest
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/blockcypher/gobcy/v2"
+)
+
+//payFwdPage is the number of payment forwards listed per page.
+const payFwdPage = 200
+
+func (s *Server) routePayFwds(r *http.Request, segs []string, q url.Values) result {
+	switch {
+	case len(segs) == 0 && r.Method == "POST":
+		var pay gobcy.PayFwd
+		if err := decode(r, &pay); err != nil {
+			return fail(http.StatusBadRequest, "%v", err)
+		}
+		if _, err := s.pkScript(pay.Destination); err != nil {
+			return fail(http.StatusBadRequest, "%v", err)
+		}
+		if len(s.payfwds) >= s.limits.PayFwds {
+			return fail(http.StatusTooManyRequests, "Limit of %d payment forwards reached", s.limits.PayFwds)
+		}
+		keys, err := s.newKey()
+		if err != nil {
+			return fail(http.StatusInternalServerError, "%v", err)
+		}
+		pay.ID = s.newID("payfwd-")
+		pay.InputAddr = keys.Address
+		s.payfwds = append(s.payfwds, pay)
+		return ok(http.StatusCreated, pay)
+	case len(segs) == 0 && r.Method == "GET":
+		start := intParam(q, "start", 0)
+		pays := []gobcy.PayFwd{}
+		for i := start; i >= 0 && i < len(s.payfwds) && len(pays) < payFwdPage; i++ {
+			pays = append(pays, s.payfwds[i])
+		}
+		return ok(http.StatusOK, pays)
+	case len(segs) == 1:
+		for i, pay := range s.payfwds {
+			if pay.ID != segs[0] {
+				continue
+			}
+			switch r.Method {
+			case "GET":
+				return ok(http.StatusOK, pay)
+			case "DELETE":
+				s.payfwds = append(s.payfwds[:i], s.payfwds[i+1:]...)
+				return ok(http.StatusNoContent, nil)
+			}
+		}
+		return fail(http.StatusNotFound, "Payment forward %s not found.", segs[0])
+	}
+	return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+}
@@ -0,0 +1,646 @@
+package gobcytest
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func (s *Server) routeTXs(r *http.Request, segs []string, q url.Values) result {
+	switch {
+	case len(segs) == 1 && r.Method == "GET":
+		var txs []gobcy.TX
+		for i := len(s.mempool) - 1; i >= 0; i-- {
+			txs = append(txs, s.txView(s.txs[s.mempool[i]], q))
+		}
+		return ok(http.StatusOK, txs)
+	case len(segs) == 2 && r.Method == "POST":
+		switch segs[1] {
+		case "new":
+			return s.newTX(r, q)
+		case "send":
+			return s.sendTX(r)
+		case "push":
+			return s.pushTX(r)
+		case "decode":
+			return s.decodeTX(r)
+		}
+	case len(segs) == 2 && r.Method == "GET":
+		if strings.Contains(segs[1], ";") {
+			var items []interface{}
+			for _, h := range strings.Split(segs[1], ";") {
+				items = append(items, addressable(s.getTX(h, q).body))
+			}
+			return ok(http.StatusOK, items)
+		}
+		return s.getTX(segs[1], q)
+	case len(segs) == 3 && segs[2] == "confidence" && r.Method == "GET":
+		e, found := s.txs[segs[1]]
+		if !found {
+			return fail(http.StatusNotFound, "Transaction %s not found.", segs[1])
+		}
+		conf := gobcy.TXConf{Age: int(time.Since(e.received) / time.Millisecond), ReceiveCount: 8, Confidence: 0.99, TXHash: e.hash}
+		if e.height >= 0 {
+			conf.Confidence = 1
+		}
+		return ok(http.StatusOK, conf)
+	case len(segs) == 3 && segs[2] == "meta":
+		return s.routeMeta(r, "tx", segs[1], q)
+	}
+	return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+}
+
+func (s *Server) getTX(hash string, q url.Values) result {
+	e, found := s.txs[hash]
+	if !found {
+		return fail(http.StatusNotFound, "Transaction %s not found.", hash)
+	}
+	return ok(http.StatusOK, s.txView(e, q))
+}
+
+//fee returns the fee paid by e.
+func (s *Server) fee(e *entry) int64 {
+	if isCoinbase(e.msg) {
+		return 0
+	}
+	var fee int64
+	for _, in := range e.msg.TxIn {
+		if prev, found := s.txs[in.PreviousOutPoint.Hash.String()]; found {
+			fee += prev.msg.TxOut[in.PreviousOutPoint.Index].Value
+		}
+	}
+	for _, out := range e.msg.TxOut {
+		fee -= out.Value
+	}
+	return fee
+}
+
+//txView returns e as a gobcy.TX, including its
+//state in the ledger.
+func (s *Server) txView(e *entry, q url.Values) gobcy.TX {
+	tx := s.msgView(e.msg, q)
+	tx.Preference = e.pref
+	tx.Received = e.received
+	tx.BlockHeight = e.height
+	tx.DoubleSpend = e.replacedBy != "" || e.replaces != ""
+	tx.DoubleOf = e.replaces
+	if e.height >= 0 {
+		b := s.blocks[e.height]
+		tx.BlockHash = b.hash
+		tx.Confirmed = b.header.Timestamp
+		tx.Confirmations = s.confirmations(e)
+		tx.Confidence = 1
+	}
+	for i := range tx.Outputs {
+		tx.Outputs[i].SpentBy = e.spentBy[i+intParam(q, "outstart", 0)]
+	}
+	tx.Fees.SetInt64(s.fee(e))
+	return tx
+}
+
+//msgView returns msg as a gobcy.TX, filling in its inputs
+//from the ledger where possible. The inputs and outputs are
+//paged by the limit, instart and outstart parameters.
+func (s *Server) msgView(msg *wire.MsgTx, q url.Values) gobcy.TX {
+	hash := msg.TxHash().String()
+	tx := gobcy.TX{
+		Hash:        hash,
+		Ver:         int(msg.Version),
+		LockTime:    int(msg.LockTime),
+		Size:        msg.SerializeSize(),
+		VirtualSize: vsize(msg),
+		VinSize:     len(msg.TxIn),
+		VoutSize:    len(msg.TxOut),
+		BlockHeight: -1,
+		Inputs:      []gobcy.TXInput{},
+		Outputs:     []gobcy.TXOutput{},
+	}
+	if q.Get("includeHex") == "true" {
+		tx.Hex = hex.EncodeToString(serialize(msg))
+	}
+	addrs := make(map[string]bool)
+	limit := intParam(q, "limit", 20)
+	inStart, outStart := intParam(q, "instart", 0), intParam(q, "outstart", 0)
+	var total int64
+	for _, out := range msg.TxOut {
+		total += out.Value
+	}
+	tx.Total.SetInt64(total)
+	for i, in := range msg.TxIn {
+		view := gobcy.TXInput{
+			PrevHash:    in.PreviousOutPoint.Hash.String(),
+			OutputIndex: int(in.PreviousOutPoint.Index),
+			Sequence:    int(in.Sequence),
+			Script:      hex.EncodeToString(in.SignatureScript),
+			ScriptType:  "empty",
+		}
+		if isCoinbase(msg) {
+			view.PrevHash, view.OutputIndex = "", -1
+		} else if prev, found := s.txs[view.PrevHash]; found && view.OutputIndex < len(prev.msg.TxOut) {
+			out := prev.msg.TxOut[view.OutputIndex]
+			view.OutputValue = int(out.Value)
+			view.Addresses, view.ScriptType = s.scriptInfo(out.PkScript)
+			if prev.height >= 0 {
+				view.Age = prev.height
+			}
+		}
+		for _, a := range view.Addresses {
+			addrs[a] = true
+		}
+		if i >= inStart && i < inStart+limit {
+			tx.Inputs = append(tx.Inputs, view)
+		}
+	}
+	for i, out := range msg.TxOut {
+		view := gobcy.TXOutput{Script: hex.EncodeToString(out.PkScript)}
+		view.Value.SetInt64(out.Value)
+		view.Addresses, view.ScriptType = s.scriptInfo(out.PkScript)
+		if view.ScriptType == "null-data" {
+			if pushes, err := txscript.PushedData(out.PkScript); err == nil && len(pushes) > 0 {
+				view.DataHex = hex.EncodeToString(pushes[0])
+				view.DataString = string(pushes[0])
+			}
+		}
+		for _, a := range view.Addresses {
+			addrs[a] = true
+		}
+		if i >= outStart && i < outStart+limit {
+			tx.Outputs = append(tx.Outputs, view)
+		}
+	}
+	tx.Addresses = sortedKeys(addrs)
+	base := s.URL() + s.Coin + "/" + s.Chain + "/txs/" + hash + "?limit=" + strconv.Itoa(limit)
+	if inStart+limit < len(msg.TxIn) {
+		tx.NextInputs = base + "&instart=" + strconv.Itoa(inStart+limit)
+	}
+	if outStart+limit < len(msg.TxOut) {
+		tx.NextOutputs = base + "&outstart=" + strconv.Itoa(outStart+limit)
+	}
+	return tx
+}
+
+//spend is an output selected as input to a new transaction.
+type spend struct {
+	op      wire.OutPoint
+	prev    *wire.TxOut
+	redeem  []byte
+	n       int
+	pubkeys []string
+	seq     uint32
+}
+
+//size estimates the bytes sp adds to a transaction.
+func (sp spend) size() int {
+	switch {
+	case sp.redeem != nil:
+		return 50 + 74*sp.n + 34*len(sp.pubkeys)
+	case txscript.IsPayToWitnessPubKeyHash(sp.prev.PkScript):
+		return 68
+	}
+	return 148
+}
+
+//multisig returns the P2SH address and redeem script of an
+//"multisig-n-of-m" script type and its pubkeys.
+func (s *Server) multisig(scriptType string, pubkeys []string) (addr string, redeem []byte, n int, err error) {
+	var m int
+	if _, err = fmt.Sscanf(scriptType, "multisig-%d-of-%d", &n, &m); err != nil || m != len(pubkeys) || n < 1 || n > m {
+		err = errors.New("Invalid script type " + scriptType + " for " + strconv.Itoa(len(pubkeys)) + " public keys")
+		return
+	}
+	var keys []*btcutil.AddressPubKey
+	for _, pk := range pubkeys {
+		raw, _ := hex.DecodeString(pk)
+		var key *btcutil.AddressPubKey
+		if key, err = btcutil.NewAddressPubKey(raw, s.params); err != nil {
+			err = errors.New("Invalid public key " + pk)
+			return
+		}
+		keys = append(keys, key)
+	}
+	if redeem, err = txscript.MultiSigScript(keys, n); err != nil {
+		return
+	}
+	sh, err := btcutil.NewAddressScriptHash(redeem, s.params)
+	if err != nil {
+		return
+	}
+	addr = sh.EncodeAddress()
+	s.redeem[addr] = redeem
+	return
+}
+
+//utxos returns the unspent outputs paying to address,
+//oldest first.
+func (s *Server) utxos(address string) (ops []wire.OutPoint) {
+	es := s.ordered()
+	for i := len(es) - 1; i >= 0; i-- {
+		e := es[i]
+		for n, out := range e.msg.TxOut {
+			if e.spentBy[n] != "" {
+				continue
+			}
+			if addrs, _ := s.scriptInfo(out.PkScript); len(addrs) == 1 && addrs[0] == address {
+				ops = append(ops, wire.OutPoint{Hash: e.msg.TxHash(), Index: uint32(n)})
+			}
+		}
+	}
+	return
+}
+
+//newTXErr is the 400 response of a failed /txs/new.
+func newTXErr(msg string) result {
+	return result{http.StatusBadRequest, map[string]interface{}{"errors": []map[string]string{{"error": msg}}}}
+}
+
+func (s *Server) newTX(r *http.Request, q url.Values) result {
+	var req gobcy.TX
+	if err := decode(r, &req); err != nil {
+		return newTXErr(err.Error())
+	}
+	if len(req.Inputs) == 0 || len(req.Outputs) == 0 {
+		return newTXErr("A transaction needs at least one input and one output")
+	}
+	tx := wire.NewMsgTx(1)
+	var outTotal int64
+	sweep := -1
+	for i, out := range req.Outputs {
+		pkScript, err := s.outputScript(out)
+		if err != nil {
+			return newTXErr(err.Error())
+		}
+		value := out.Value.Int64()
+		if value == -1 {
+			sweep = i
+			value = 0
+		}
+		outTotal += value
+		tx.AddTxOut(wire.NewTxOut(value, pkScript))
+	}
+	//resolve inputs to candidate outputs, by outpoint or address
+	var spends, candidates []spend
+	var changeAddr string
+	used := make(map[wire.OutPoint]bool)
+	for _, in := range req.Inputs {
+		if in.PrevHash != "" {
+			hash, err := chainhash.NewHashFromStr(in.PrevHash)
+			if err != nil {
+				return newTXErr("Invalid prev_hash " + in.PrevHash)
+			}
+			op := wire.OutPoint{Hash: *hash, Index: uint32(in.OutputIndex)}
+			sp, err := s.spendOf(op, in)
+			if err != nil {
+				return newTXErr(err.Error())
+			}
+			if as, _ := s.scriptInfo(sp.prev.PkScript); changeAddr == "" && len(as) == 1 {
+				changeAddr = as[0]
+			}
+			spends = append(spends, sp)
+			used[op] = true
+			continue
+		}
+		var sources []string
+		sp := spend{pubkeys: nil, seq: uint32(in.Sequence)}
+		if strings.HasPrefix(in.ScriptType, "multisig-") {
+			addr, redeem, n, err := s.multisig(in.ScriptType, in.Addresses)
+			if err != nil {
+				return newTXErr(err.Error())
+			}
+			sources = []string{addr}
+			sp.redeem, sp.n, sp.pubkeys = redeem, n, in.Addresses
+		} else {
+			for _, a := range in.Addresses {
+				if w, found := s.wallets[a]; found {
+					sources = append(sources, w.Addresses...)
+				} else {
+					sources = append(sources, a)
+				}
+			}
+		}
+		if changeAddr == "" && len(sources) > 0 {
+			changeAddr = sources[0]
+		}
+		for _, a := range sources {
+			for _, op := range s.utxos(a) {
+				c := sp
+				c.op = op
+				c.prev = s.txs[op.Hash.String()].msg.TxOut[op.Index]
+				candidates = append(candidates, c)
+			}
+		}
+	}
+	if req.ChangeAddress != "" {
+		changeAddr = req.ChangeAddress
+	}
+	rate := int64(highFee)
+	switch req.Preference {
+	case "medium":
+		rate = mediumFee
+	case "low":
+		rate = lowFee
+	case "zero":
+		rate = 0
+	}
+	feeFor := func(spends []spend, outs int) int64 {
+		if req.Fees.Sign() > 0 {
+			return req.Fees.Int64()
+		}
+		size := 10 + 34*outs
+		for _, sp := range spends {
+			size += sp.size()
+		}
+		return rate * int64(size) / 1000
+	}
+	inTotal := func() (total int64) {
+		for _, sp := range spends {
+			total += sp.prev.Value
+		}
+		return
+	}
+	for _, c := range candidates {
+		if sweep < 0 && len(spends) > 0 && inTotal() >= outTotal+feeFor(spends, len(tx.TxOut)+1) {
+			break
+		}
+		if !used[c.op] {
+			spends = append(spends, c)
+			used[c.op] = true
+		}
+	}
+	fee := feeFor(spends, len(tx.TxOut)+1)
+	if sweep >= 0 {
+		fee = feeFor(spends, len(tx.TxOut))
+		tx.TxOut[sweep].Value = inTotal() - outTotal - fee
+	}
+	change := inTotal() - outTotal - fee
+	if sweep >= 0 {
+		change = 0
+	}
+	if change < 0 || len(spends) == 0 || (sweep >= 0 && tx.TxOut[sweep].Value <= 0) {
+		return newTXErr(fmt.Sprintf("Not enough funds in %d inputs to pay for %d outputs, missing %d.", len(spends), len(tx.TxOut), -change))
+	}
+	if change > 0 {
+		pkScript, err := s.pkScript(changeAddr)
+		if err != nil {
+			return newTXErr("Invalid change address: " + err.Error())
+		}
+		tx.AddTxOut(wire.NewTxOut(change, pkScript))
+	}
+	for _, sp := range spends {
+		txIn := wire.NewTxIn(&sp.op, nil, nil)
+		if sp.seq != 0 {
+			txIn.Sequence = sp.seq
+		}
+		tx.AddTxIn(txIn)
+	}
+	skel := gobcy.TXSkel{Trans: s.msgView(tx, url.Values{"limit": {strconv.Itoa(len(spends) + len(tx.TxOut))}}), ToSign: []string{}, Signatures: []string{}}
+	skel.Trans.Preference = req.Preference
+	skel.Trans.Fees.SetInt64(fee)
+	for i, sp := range spends {
+		var pre []byte
+		copies := 1
+		switch {
+		case sp.redeem != nil:
+			pre, copies = legacyPreimage(tx, i, sp.redeem), sp.n
+			skel.Trans.Inputs[i].Addresses = sp.pubkeys
+			skel.Trans.Inputs[i].ScriptType = "multisig-" + strconv.Itoa(sp.n) + "-of-" + strconv.Itoa(len(sp.pubkeys))
+		case txscript.IsPayToPubKeyHash(sp.prev.PkScript):
+			pre = legacyPreimage(tx, i, sp.prev.PkScript)
+		case txscript.IsPayToWitnessPubKeyHash(sp.prev.PkScript):
+			pre = witnessPreimage(tx, i, p2pkhCode(sp.prev.PkScript[2:]), sp.prev.Value)
+		default:
+			return newTXErr("Unsupported script type spending " + sp.op.String())
+		}
+		for c := 0; c < copies; c++ {
+			skel.ToSign = append(skel.ToSign, hex.EncodeToString(chainhash.DoubleHashB(pre)))
+			if q.Get("includeToSignTx") == "true" {
+				skel.ToSignTX = append(skel.ToSignTX, hex.EncodeToString(pre))
+			}
+		}
+	}
+	return ok(http.StatusCreated, skel)
+}
+
+//spendOf returns the spend of an explicitly given outpoint.
+func (s *Server) spendOf(op wire.OutPoint, in gobcy.TXInput) (sp spend, err error) {
+	sp.op, sp.seq = op, uint32(in.Sequence)
+	if sp.prev, err = s.prevOut(op); err != nil {
+		return
+	}
+	if strings.HasPrefix(in.ScriptType, "multisig-") {
+		_, sp.redeem, sp.n, err = s.multisig(in.ScriptType, in.Addresses)
+		sp.pubkeys = in.Addresses
+	}
+	return
+}
+
+//outputScript returns the script of a requested output.
+func (s *Server) outputScript(out gobcy.TXOutput) ([]byte, error) {
+	switch {
+	case out.ScriptType == "null-data":
+		data := []byte(out.DataString)
+		if out.DataHex != "" {
+			data, _ = hex.DecodeString(out.DataHex)
+		}
+		return txscript.NullDataScript(data)
+	case strings.HasPrefix(out.ScriptType, "multisig-"):
+		addr, _, _, err := s.multisig(out.ScriptType, out.Addresses)
+		if err != nil {
+			return nil, err
+		}
+		return s.pkScript(addr)
+	case out.Script != "" && len(out.Addresses) == 0:
+		return hex.DecodeString(out.Script)
+	case len(out.Addresses) != 1:
+		return nil, errors.New("Each output needs exactly one address")
+	}
+	return s.pkScript(out.Addresses[0])
+}
+
+//p2pkhCode returns the P2PKH script of a 20-byte
+//key hash, as used to sign P2WPKH inputs.
+func p2pkhCode(keyHash []byte) []byte {
+	code, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+		AddData(keyHash).AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).Script()
+	return code
+}
+
+func (s *Server) sendTX(r *http.Request) result {
+	var skel gobcy.TXSkel
+	if err := decode(r, &skel); err != nil {
+		return newTXErr(err.Error())
+	}
+	tx, err := s.rebuild(skel.Trans)
+	if err != nil {
+		return newTXErr(err.Error())
+	}
+	//pubkeys are parallel to signatures when their lengths match;
+	//otherwise they only belong to the single-signature inputs
+	parallel := len(skel.PubKeys) == len(skel.Signatures)
+	sigs, pubs := skel.Signatures, skel.PubKeys
+	next := func(single bool) (sig []byte, pub []byte, err error) {
+		if len(sigs) == 0 {
+			err = errors.New("Not enough signatures")
+			return
+		}
+		if sig, err = hex.DecodeString(sigs[0]); err != nil {
+			return
+		}
+		sig = append(sig, byte(txscript.SigHashAll))
+		sigs = sigs[1:]
+		if !parallel && !single {
+			return
+		}
+		if len(pubs) == 0 {
+			err = errors.New("Not enough public keys")
+			return
+		}
+		pub, err = hex.DecodeString(pubs[0])
+		pubs = pubs[1:]
+		return
+	}
+	for i, in := range skel.Trans.Inputs {
+		prev, err := s.prevOut(tx.TxIn[i].PreviousOutPoint)
+		if err != nil {
+			return newTXErr(err.Error())
+		}
+		txIn := tx.TxIn[i]
+		if strings.HasPrefix(in.ScriptType, "multisig-") {
+			_, redeem, n, err := s.multisig(in.ScriptType, in.Addresses)
+			if err != nil {
+				return newTXErr(err.Error())
+			}
+			b := txscript.NewScriptBuilder().AddOp(txscript.OP_0)
+			for k := 0; k < n; k++ {
+				sig, _, err := next(false)
+				if err != nil {
+					return newTXErr(err.Error())
+				}
+				b.AddData(sig)
+			}
+			txIn.SignatureScript, _ = b.AddData(redeem).Script()
+			continue
+		}
+		sig, pub, err := next(true)
+		if err != nil {
+			return newTXErr(err.Error())
+		}
+		switch {
+		case txscript.IsPayToPubKeyHash(prev.PkScript):
+			txIn.SignatureScript, _ = txscript.NewScriptBuilder().AddData(sig).AddData(pub).Script()
+		case txscript.IsPayToWitnessPubKeyHash(prev.PkScript):
+			txIn.Witness = wire.TxWitness{sig, pub}
+		case txscript.IsPayToScriptHash(prev.PkScript):
+			redeem := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, btcutil.Hash160(pub)...)
+			txIn.SignatureScript, _ = txscript.NewScriptBuilder().AddData(redeem).Script()
+			txIn.Witness = wire.TxWitness{sig, pub}
+		default:
+			return newTXErr("Unsupported script type spending input " + strconv.Itoa(i))
+		}
+	}
+	e, err := s.insert(tx, skel.Trans.Preference)
+	if err != nil {
+		return newTXErr(err.Error())
+	}
+	skel.Trans = s.txView(e, url.Values{})
+	return ok(http.StatusCreated, skel)
+}
+
+//rebuild returns the unsigned transaction described by trans.
+func (s *Server) rebuild(trans gobcy.TX) (*wire.MsgTx, error) {
+	ver := int32(trans.Ver)
+	if ver == 0 {
+		ver = 1
+	}
+	tx := wire.NewMsgTx(ver)
+	tx.LockTime = uint32(trans.LockTime)
+	for _, in := range trans.Inputs {
+		hash, err := chainhash.NewHashFromStr(in.PrevHash)
+		if err != nil {
+			return nil, errors.New("Invalid prev_hash " + in.PrevHash)
+		}
+		txIn := wire.NewTxIn(wire.NewOutPoint(hash, uint32(in.OutputIndex)), nil, nil)
+		if in.Sequence != 0 {
+			txIn.Sequence = uint32(in.Sequence)
+		}
+		tx.AddTxIn(txIn)
+	}
+	for _, out := range trans.Outputs {
+		pkScript, err := hex.DecodeString(out.Script)
+		if err != nil || len(pkScript) == 0 {
+			if pkScript, err = s.outputScript(out); err != nil {
+				return nil, err
+			}
+		}
+		tx.AddTxOut(wire.NewTxOut(out.Value.Int64(), pkScript))
+	}
+	return tx, nil
+}
+
+func (s *Server) pushTX(r *http.Request) result {
+	var req struct {
+		TX string `json:"tx"`
+	}
+	if err := decode(r, &req); err != nil {
+		return fail(http.StatusBadRequest, "%v", err)
+	}
+	tx, err := parseTX(req.TX)
+	if err != nil {
+		return fail(http.StatusBadRequest, "%v", err)
+	}
+	e, err := s.insert(tx, "")
+	if err != nil {
+		return fail(http.StatusBadRequest, "%v", err)
+	}
+	view := s.txView(e, url.Values{})
+	return ok(http.StatusCreated, map[string]*gobcy.TX{"tx": &view})
+}
+
+func (s *Server) decodeTX(r *http.Request) result {
+	var req struct {
+		TX string `json:"tx"`
+	}
+	if err := decode(r, &req); err != nil {
+		return fail(http.StatusBadRequest, "%v", err)
+	}
+	tx, err := parseTX(req.TX)
+	if err != nil {
+		return fail(http.StatusBadRequest, "%v", err)
+	}
+	return ok(http.StatusOK, s.msgView(tx, url.Values{"limit": {"1000000"}}))
+}
+
+//newKey returns a new private key and its P2PKH address.
+func (s *Server) newKey() (keys gobcy.AddrKeychain, err error) {
+	priv, err := btcec.NewPrivateKey()
+	if err != nil {
+		return
+	}
+	pub := priv.PubKey().SerializeCompressed()
+	addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pub), s.params)
+	if err != nil {
+		return
+	}
+	wif, err := btcutil.NewWIF(priv, s.params, true)
+	if err != nil {
+		return
+	}
+	keys = gobcy.AddrKeychain{
+		Address: addr.EncodeAddress(),
+		Private: hex.EncodeToString(priv.Serialize()),
+		Public:  hex.EncodeToString(pub),
+		Wif:     wif.String(),
+	}
+	return
+}
@@ -0,0 +1,112 @@
+package gobcytest
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/blockcypher/gobcy/v2"
+)
+
+func (s *Server) routeWallets(r *http.Request, segs []string, q url.Values) result {
+	if len(segs) == 0 {
+		switch r.Method {
+		case "POST":
+			var req gobcy.Wallet
+			if err := decode(r, &req); err != nil {
+				return fail(http.StatusBadRequest, "%v", err)
+			}
+			if req.Name == "" {
+				return fail(http.StatusBadRequest, "A wallet needs a name")
+			}
+			if s.walletExists(req.Name) {
+				return fail(http.StatusConflict, "Wallet %s already exists", req.Name)
+			}
+			for _, a := range req.Addresses {
+				if _, err := s.pkScript(a); err != nil {
+					return fail(http.StatusBadRequest, "%v", err)
+				}
+			}
+			w := &gobcy.Wallet{Name: req.Name, Addresses: append([]string{}, req.Addresses...)}
+			s.wallets[w.Name] = w
+			return ok(http.StatusCreated, w)
+		case "GET":
+			var names []string
+			for name := range s.wallets {
+				names = append(names, name)
+			}
+			for name := range s.hdwallets {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return ok(http.StatusOK, map[string][]string{"wallet_names": names})
+		}
+		return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+	}
+	w, found := s.wallets[segs[0]]
+	if !found {
+		return fail(http.StatusNotFound, "Wallet %s not found.", segs[0])
+	}
+	switch {
+	case len(segs) == 1 && r.Method == "GET":
+		return ok(http.StatusOK, w)
+	case len(segs) == 1 && r.Method == "DELETE":
+		delete(s.wallets, w.Name)
+		return ok(http.StatusNoContent, nil)
+	case len(segs) == 2 && segs[1] == "addresses" && r.Method == "GET":
+		return ok(http.StatusOK, w)
+	case len(segs) == 2 && segs[1] == "addresses" && r.Method == "POST":
+		var req gobcy.Wallet
+		if err := decode(r, &req); err != nil {
+			return fail(http.StatusBadRequest, "%v", err)
+		}
+		for _, a := range req.Addresses {
+			if _, err := s.pkScript(a); err != nil {
+				return fail(http.StatusBadRequest, "%v", err)
+			}
+			if !contains(w.Addresses, a) {
+				w.Addresses = append(w.Addresses, a)
+			}
+		}
+		return ok(http.StatusOK, w)
+	case len(segs) == 2 && segs[1] == "addresses" && r.Method == "DELETE":
+		remove := strings.Split(q.Get("address"), ";")
+		var kept []string
+		for _, a := range w.Addresses {
+			if !contains(remove, a) {
+				kept = append(kept, a)
+			}
+		}
+		w.Addresses = kept
+		return ok(http.StatusNoContent, nil)
+	case len(segs) == 3 && segs[1] == "addresses" && segs[2] == "generate" && r.Method == "POST":
+		keys, err := s.newKey()
+		if err != nil {
+			return fail(http.StatusInternalServerError, "%v", err)
+		}
+		w.Addresses = append(w.Addresses, keys.Address)
+		return ok(http.StatusOK, struct {
+			*gobcy.Wallet
+			gobcy.AddrKeychain
+		}{w, keys})
+	}
+	return fail(http.StatusNotFound, "Unknown endpoint %s %s", r.Method, r.URL.Path)
+}
+
+//walletExists reports whether a wallet or HD
+//wallet is already using name.
+func (s *Server) walletExists(name string) bool {
+	_, found := s.wallets[name]
+	_, hdFound := s.hdwallets[name]
+	return found || hdFound
+}
+
+func contains(list []string, v string) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
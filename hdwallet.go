@@ -1,5 +1,7 @@
 package gobcy
 
+import "context"
+
 //CreateHDWallet creates a public-address watching HDWallet
 //associated with this token/coin/chain, usable anywhere
 //in the API where an Address might be used (just use
@@ -7,11 +9,17 @@ package gobcy
 //a wallet name balance:
 //  addr, err := api.GetAddrBal("your-hd-wallet-name")
 func (api *API) CreateHDWallet(req HDWallet) (wal HDWallet, err error) {
+	return api.CreateHDWalletCtx(context.Background(), req)
+}
+
+//CreateHDWalletCtx is like CreateHDWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) CreateHDWalletCtx(ctx context.Context, req HDWallet) (wal HDWallet, err error) {
 	u, err := api.buildURL("/wallets/hd", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &req, &wal)
+	err = api.postResponse(ctx, u, &req, &wal)
 	return
 }
 
@@ -37,45 +45,71 @@ func (api *API) CreateHDWallet(req HDWallet) (wal HDWallet, err error) {
 //GetHDWallet gets a HDWallet based on its name
 //and the associated API token/coin/chain.
 func (api *API) GetHDWallet(name string) (wal HDWallet, err error) {
+	return api.GetHDWalletCtx(context.Background(), name)
+}
+
+//GetHDWalletCtx is like GetHDWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetHDWalletCtx(ctx context.Context, name string) (wal HDWallet, err error) {
 	u, err := api.buildURL("/wallets/hd/"+name, nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &wal)
+	err = api.getResponse(ctx, u, &wal)
 	return
 }
 
 //GetAddrHDWallet returns addresses associated with
 //a named HDWallet, associated with the API token/coin/chain.
 //It also optionally accepts URL parameters.
+//See AddrQuery for its supported params.
 func (api *API) GetAddrHDWallet(name string, params map[string]string) (addrs HDWallet, err error) {
+	return api.GetAddrHDWalletCtx(context.Background(), name, params)
+}
+
+//GetAddrHDWalletCtx is like GetAddrHDWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetAddrHDWalletCtx(ctx context.Context, name string, params map[string]string) (addrs HDWallet, err error) {
 	u, err := api.buildURL("/wallets/hd/"+name+"/addresses", params)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &addrs)
+	err = api.getResponse(ctx, u, &addrs)
 	return
 }
 
 //DeriveAddrHDWallet derives a new address within the named Wallet,
 //associated with the API token/coin/chain. It will only return a partial
 //HDWallet, ONLY containing the new address derived.
+//See DeriveQuery for its supported params.
 func (api *API) DeriveAddrHDWallet(name string, params map[string]string) (wal HDWallet, err error) {
+	return api.DeriveAddrHDWalletCtx(context.Background(), name, params)
+}
+
+//DeriveAddrHDWalletCtx is like DeriveAddrHDWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) DeriveAddrHDWalletCtx(ctx context.Context, name string, params map[string]string) (wal HDWallet, err error) {
 	u, err := api.buildURL("/wallets/hd/"+name+"/addresses/derive", params)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, nil, &wal)
+	err = api.postResponse(ctx, u, nil, &wal)
 	return
 }
 
 //DeleteHDWallet deletes a named HDWallet associated with the
 //API token/coin/chain.
 func (api *API) DeleteHDWallet(name string) (err error) {
+	return api.DeleteHDWalletCtx(context.Background(), name)
+}
+
+//DeleteHDWalletCtx is like DeleteHDWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) DeleteHDWalletCtx(ctx context.Context, name string) (err error) {
 	u, err := api.buildURL("/wallets/hd/"+name, nil)
 	if err != nil {
 		return
 	}
-	err = deleteResponse(u)
+	err = api.deleteResponse(ctx, u)
 	return
 }
@@ -0,0 +1,22 @@
+package gobcy_test
+
+import (
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+)
+
+//fundedKeys returns a new keychain funded with amount by
+//the faucet of srv, confirmed in a new block.
+func fundedKeys(t *testing.T, srv *gobcytest.Server, bc *gobcy.API, amount int) gobcy.AddrKeychain {
+	keys, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	if _, err = bc.Faucet(keys, amount); err != nil {
+		t.Fatal("Faucet error encountered: ", err)
+	}
+	srv.Mine()
+	return keys
+}
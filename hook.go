@@ -1,45 +1,71 @@
 package gobcy
 
+import "context"
+
 //CreateHook creates a new WebHook associated
 //with your API.Token, and returns a WebHook
 //with a BlockCypher-assigned id.
 func (api *API) CreateHook(hook Hook) (result Hook, err error) {
+	return api.CreateHookCtx(context.Background(), hook)
+}
+
+//CreateHookCtx is like CreateHook, but uses ctx
+//for the underlying HTTP request.
+func (api *API) CreateHookCtx(ctx context.Context, hook Hook) (result Hook, err error) {
 	u, err := api.buildURL("/hooks", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &hook, &result)
+	err = api.postResponse(ctx, u, &hook, &result)
 	return
 }
 
 //ListHooks returns a slice of WebHooks
 //associated with your API.Token.
 func (api *API) ListHooks() (hooks []Hook, err error) {
+	return api.ListHooksCtx(context.Background())
+}
+
+//ListHooksCtx is like ListHooks, but uses ctx
+//for the underlying HTTP request.
+func (api *API) ListHooksCtx(ctx context.Context) (hooks []Hook, err error) {
 	u, err := api.buildURL("/hooks", nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &hooks)
+	err = api.getResponse(ctx, u, &hooks)
 	return
 }
 
 //GetHook returns a WebHook by its id.
 func (api *API) GetHook(id string) (hook Hook, err error) {
+	return api.GetHookCtx(context.Background(), id)
+}
+
+//GetHookCtx is like GetHook, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetHookCtx(ctx context.Context, id string) (hook Hook, err error) {
 	u, err := api.buildURL("/hooks/"+id, nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &hook)
+	err = api.getResponse(ctx, u, &hook)
 	return
 }
 
 //DeleteHook deletes a WebHook notification
 //from BlockCypher's database, based on its id.
 func (api *API) DeleteHook(id string) (err error) {
+	return api.DeleteHookCtx(context.Background(), id)
+}
+
+//DeleteHookCtx is like DeleteHook, but uses ctx
+//for the underlying HTTP request.
+func (api *API) DeleteHookCtx(ctx context.Context, id string) (err error) {
 	u, err := api.buildURL("/hooks/"+id, nil)
 	if err != nil {
 		return
 	}
-	err = deleteResponse(u)
+	err = api.deleteResponse(ctx, u)
 	return
 }
@@ -0,0 +1,227 @@
+package gobcy
+
+import (
+	"context"
+	"math"
+	"strconv"
+)
+
+//addrPager fetches the successive pages of an address's
+//TXRefs or TXs, moving a "before" block height cursor
+//down the chain while keeping the caller's other params.
+type addrPager struct {
+	api      *API
+	ctx      context.Context
+	hash     string
+	params   map[string]string
+	full     bool
+	limit    int
+	maxLimit int
+	before   int
+	started  bool
+	done     bool
+	err      error
+}
+
+func newAddrPager(ctx context.Context, api *API, hash string, params map[string]string, full bool) *addrPager {
+	p := &addrPager{api: api, ctx: ctx, hash: hash, params: make(map[string]string), full: full}
+	for k, v := range params {
+		p.params[k] = v
+	}
+	//BlockCypher's default and largest page sizes
+	p.limit, p.maxLimit = 50, 2000
+	if full {
+		p.limit, p.maxLimit = 10, 50
+	}
+	if v, err := strconv.Atoi(params["limit"]); err == nil && v > 0 {
+		p.limit = v
+		if v > p.maxLimit {
+			p.maxLimit = v
+		}
+	}
+	return p
+}
+
+//fetch gets the page at the cursor.
+func (p *addrPager) fetch() (addr Addr, err error) {
+	params := make(map[string]string, len(p.params)+2)
+	for k, v := range p.params {
+		params[k] = v
+	}
+	params["limit"] = strconv.Itoa(p.limit)
+	if p.started {
+		params["before"] = strconv.Itoa(p.before)
+	}
+	if p.full {
+		return p.api.GetAddrFullCtx(p.ctx, p.hash, params)
+	}
+	return p.api.GetAddrCtx(p.ctx, p.hash, params)
+}
+
+//advance moves the cursor past a page whose last confirmed
+//item is at height last (-1 if it had none), and which had
+//fresh items not seen on earlier pages.
+func (p *addrPager) advance(hasMore bool, last, fresh int) {
+	if !hasMore {
+		p.done = true
+		return
+	}
+	next := last + 1
+	if last < 0 || (fresh == 0 && p.started && next >= p.before) {
+		//the whole page was unconfirmed, which "before" can't
+		//page through, or at a height we've already seen; ask
+		//for more at once, or as a last resort skip the rest
+		if p.limit < p.maxLimit {
+			p.limit *= 2
+			if p.limit > p.maxLimit {
+				p.limit = p.maxLimit
+			}
+			return
+		}
+		if last < 0 && p.started {
+			p.done = true
+			return
+		}
+		next = last
+		if last < 0 {
+			next = math.MaxInt32
+		}
+	}
+	p.before, p.started = next, true
+}
+
+//TXRefIter iterates over every TXRef of an address or wallet,
+//fetching pages as needed. Use it like so:
+//	it := bc.IterAddr(addr, nil)
+//	for it.Next() {
+//		ref := it.TXRef()
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type TXRefIter struct {
+	p    *addrPager
+	refs []TXRef
+	ref  TXRef
+	seen map[string]bool
+}
+
+//IterAddr returns a TXRefIter over all TXRefs of an address,
+//or of a Wallet/HDWallet by name. Unconfirmed TXRefs come first,
+//then confirmed ones by descending block height. Each page keeps
+//the given params (see AddrQuery), with "before" and "limit"
+//managed by the iterator, so TXRefs sharing a block with a page
+//boundary are neither skipped nor repeated. The one exception
+//is a block (or the unconfirmed set) with more TXRefs than
+//BlockCypher's largest page, whose remainder is skipped.
+func (api *API) IterAddr(hash string, params map[string]string) *TXRefIter {
+	return api.IterAddrCtx(context.Background(), hash, params)
+}
+
+//IterAddrCtx is like IterAddr, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) IterAddrCtx(ctx context.Context, hash string, params map[string]string) *TXRefIter {
+	return &TXRefIter{p: newAddrPager(ctx, api, hash, params, false), seen: make(map[string]bool)}
+}
+
+//Next advances to the next TXRef, returning false when
+//there are none left or an error occurred; see Err.
+func (it *TXRefIter) Next() bool {
+	for len(it.refs) == 0 {
+		if it.p.done || it.p.err != nil {
+			return false
+		}
+		addr, err := it.p.fetch()
+		if err != nil {
+			it.p.err = err
+			return false
+		}
+		fresh := 0
+		for _, ref := range append(addr.UnconfirmedTXRefs, addr.TXRefs...) {
+			key := ref.TXHash + "/" + strconv.Itoa(ref.TXInputN) + "/" + strconv.Itoa(ref.TXOutputN)
+			if !it.seen[key] {
+				it.seen[key] = true
+				it.refs = append(it.refs, ref)
+				fresh++
+			}
+		}
+		last := -1
+		if n := len(addr.TXRefs); n > 0 {
+			last = addr.TXRefs[n-1].BlockHeight
+		}
+		it.p.advance(addr.HasMore, last, fresh)
+	}
+	it.ref, it.refs = it.refs[0], it.refs[1:]
+	return true
+}
+
+//TXRef returns the current TXRef.
+func (it *TXRefIter) TXRef() TXRef {
+	return it.ref
+}
+
+//Err returns the error that stopped the iteration, if any.
+func (it *TXRefIter) Err() error {
+	return it.p.err
+}
+
+//TXIter iterates over every full TX of an address or
+//wallet, fetching pages as needed; see IterAddrFull.
+type TXIter struct {
+	p    *addrPager
+	txs  []TX
+	tx   TX
+	seen map[string]bool
+}
+
+//IterAddrFull returns a TXIter over all TXs of an address,
+//or of a Wallet/HDWallet by name, paging like IterAddr.
+func (api *API) IterAddrFull(hash string, params map[string]string) *TXIter {
+	return api.IterAddrFullCtx(context.Background(), hash, params)
+}
+
+//IterAddrFullCtx is like IterAddrFull, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) IterAddrFullCtx(ctx context.Context, hash string, params map[string]string) *TXIter {
+	return &TXIter{p: newAddrPager(ctx, api, hash, params, true), seen: make(map[string]bool)}
+}
+
+//Next advances to the next TX, returning false when
+//there are none left or an error occurred; see Err.
+func (it *TXIter) Next() bool {
+	for len(it.txs) == 0 {
+		if it.p.done || it.p.err != nil {
+			return false
+		}
+		addr, err := it.p.fetch()
+		if err != nil {
+			it.p.err = err
+			return false
+		}
+		fresh := 0
+		for _, tx := range addr.TXs {
+			if !it.seen[tx.Hash] {
+				it.seen[tx.Hash] = true
+				it.txs = append(it.txs, tx)
+				fresh++
+			}
+		}
+		last := -1
+		if n := len(addr.TXs); n > 0 {
+			last = addr.TXs[n-1].BlockHeight
+		}
+		it.p.advance(addr.HasMore, last, fresh)
+	}
+	it.tx, it.txs = it.txs[0], it.txs[1:]
+	return true
+}
+
+//TX returns the current TX.
+func (it *TXIter) TX() TX {
+	return it.tx
+}
+
+//Err returns the error that stopped the iteration, if any.
+func (it *TXIter) Err() error {
+	return it.p.err
+}
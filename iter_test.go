@@ -0,0 +1,66 @@
+package gobcy_test
+
+import (
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+)
+
+func TestIterAddr(t *testing.T) {
+	srv := gobcytest.NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	keys, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	//three blocks with one tx, one block with seven,
+	//then two unconfirmed txs
+	for i := 0; i < 3; i++ {
+		srv.Fund(keys.Address, 1000)
+		srv.Mine()
+	}
+	for i := 0; i < 7; i++ {
+		srv.Fund(keys.Address, 1000)
+	}
+	srv.Mine()
+	srv.Fund(keys.Address, 1000)
+	srv.Fund(keys.Address, 1000)
+	seen := make(map[string]bool)
+	it := bc.IterAddr(keys.Address, map[string]string{"limit": "2"})
+	for n := 0; it.Next(); n++ {
+		ref := it.TXRef()
+		if seen[ref.TXHash] {
+			t.Errorf("IterAddr repeated %s", ref.TXHash)
+		}
+		seen[ref.TXHash] = true
+		if (n < 2) != (ref.BlockHeight == -1) {
+			t.Errorf("IterAddr returned ref %d at height %d", n, ref.BlockHeight)
+		}
+	}
+	if err = it.Err(); err != nil {
+		t.Fatal("IterAddr error encountered: ", err)
+	}
+	if len(seen) != 12 {
+		t.Errorf("IterAddr returned %d refs, expected 12", len(seen))
+	}
+	//the caller's params are kept on every page
+	n := 0
+	for it = bc.IterAddr(keys.Address, map[string]string{"limit": "3", "confirmations": "1"}); it.Next(); n++ {
+	}
+	if err = it.Err(); err != nil || n != 10 {
+		t.Errorf("IterAddr with confirmations returned %d refs, %v; expected 10", n, err)
+	}
+	n = 0
+	full := bc.IterAddrFull(keys.Address, map[string]string{"limit": "1"})
+	for ; full.Next(); n++ {
+	}
+	if err = full.Err(); err != nil || n != 12 {
+		t.Errorf("IterAddrFull returned %d txs, %v; expected 12", n, err)
+	}
+	//errors stop the iteration
+	it = bc.IterAddr("notanaddress", nil)
+	if it.Next() || it.Err() == nil {
+		t.Error("Expected error iterating an invalid address, did not receive one")
+	}
+}
@@ -0,0 +1,100 @@
+package gobcy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"golang.org/x/crypto/scrypt"
+)
+
+//keystore is the JSON encoding of a sealed keystore.
+type keystore struct {
+	Version    int    `json:"version"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+//keystoreKey derives the AES-256 key of a keystore
+//from its passphrase with scrypt.
+func keystoreKey(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, 1<<15, 8, 1, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+//SealKeystore encrypts WIF-encoded private keys with
+//passphrase, returning a keystore to save and later
+//open with OpenKeystore.
+func SealKeystore(wifs []string, passphrase string) (data []byte, err error) {
+	for _, wif := range wifs {
+		if _, err = btcutil.DecodeWIF(wif); err != nil {
+			return
+		}
+	}
+	plain, err := json.Marshal(wifs)
+	if err != nil {
+		return
+	}
+	ks := keystore{Version: 1, Salt: make([]byte, 16)}
+	if _, err = rand.Read(ks.Salt); err != nil {
+		return
+	}
+	aead, err := keystoreKey(passphrase, ks.Salt)
+	if err != nil {
+		return
+	}
+	ks.Nonce = make([]byte, aead.NonceSize())
+	if _, err = rand.Read(ks.Nonce); err != nil {
+		return
+	}
+	ks.Ciphertext = aead.Seal(nil, ks.Nonce, plain, nil)
+	return json.Marshal(ks)
+}
+
+//OpenKeystore decrypts a keystore made by SealKeystore,
+//returning a KeySigner holding its keys.
+func OpenKeystore(data []byte, passphrase string) (signer *KeySigner, err error) {
+	var ks keystore
+	if err = json.Unmarshal(data, &ks); err != nil {
+		return
+	}
+	if ks.Version != 1 {
+		err = errors.New("Func OpenKeystore: unsupported keystore version")
+		return
+	}
+	aead, err := keystoreKey(passphrase, ks.Salt)
+	if err != nil {
+		return
+	}
+	if len(ks.Nonce) != aead.NonceSize() {
+		err = errors.New("Func OpenKeystore: corrupt keystore")
+		return
+	}
+	plain, err := aead.Open(nil, ks.Nonce, ks.Ciphertext, nil)
+	if err != nil {
+		err = errors.New("Func OpenKeystore: wrong passphrase or corrupt keystore")
+		return
+	}
+	var wifs []string
+	if err = json.Unmarshal(plain, &wifs); err != nil {
+		return
+	}
+	signer = NewKeySigner()
+	for _, wif := range wifs {
+		if err = signer.AddWIF(wif); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
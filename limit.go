@@ -0,0 +1,211 @@
+package gobcy
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Limiter throttles requests made through an API to stay within
+//a token's limits: api/second, api/hour and api/day for every
+//request, plus hooks/hour for CreateHook and confidence/hour for
+//GetTXConf and requests with includeConfidence set. Limits of 0
+//are not enforced.
+//A Limiter is usually seeded from CheckUsage, so it starts from
+//the hits your token has already made this hour and day:
+//	usage, err := gobcy.NewAPI("your-token", "btc", "main").CheckUsage()
+//	...
+//	bc := gobcy.NewAPI("your-token", "btc", "main",
+//		gobcy.WithLimiter(gobcy.NewLimiter(usage)))
+//A single Limiter may be shared between APIs using the same token.
+type Limiter struct {
+	mu     sync.Mutex
+	limits Usage
+	recent []time.Time
+	hour   window
+	day    window
+	hooks  window
+	conf   window
+}
+
+//window counts hits within a fixed, clock-aligned period.
+type window struct {
+	period time.Duration
+	start  time.Time
+	hits   int
+}
+
+//roll resets the window if now is past its period.
+func (w *window) roll(now time.Time) {
+	if start := now.Truncate(w.period); !start.Equal(w.start) {
+		w.start = start
+		w.hits = 0
+	}
+}
+
+//wait returns how long until the window allows another
+//hit under limit, or 0 if it allows one now.
+func (w *window) wait(now time.Time, limit int) time.Duration {
+	w.roll(now)
+	if limit <= 0 || w.hits < limit {
+		return 0
+	}
+	return w.start.Add(w.period).Sub(now)
+}
+
+//limitKind is the set of limits a request counts against,
+//beyond the api/second, api/hour and api/day limits.
+type limitKind int
+
+const (
+	limitAPI limitKind = iota
+	limitHook
+	limitConf
+)
+
+//NewLimiter returns a Limiter enforcing usage.Limits, having
+//already counted usage.Hits against the current hour and day.
+//To configure a Limiter manually, pass only Limits:
+//	gobcy.NewLimiter(gobcy.TokenUsage{Limits: gobcy.Usage{PerSec: 3, PerHour: 200}})
+func NewLimiter(usage TokenUsage) *Limiter {
+	now := time.Now()
+	l := &Limiter{
+		limits: usage.Limits,
+		hour:   window{period: time.Hour},
+		day:    window{period: 24 * time.Hour},
+		hooks:  window{period: time.Hour},
+		conf:   window{period: time.Hour},
+	}
+	for _, w := range []*window{&l.hour, &l.day, &l.hooks, &l.conf} {
+		w.roll(now)
+	}
+	l.hour.hits = usage.Hits.PerHour
+	l.day.hits = usage.Hits.PerDay
+	l.hooks.hits = usage.Hits.HooksPerHour
+	l.conf.hits = usage.Hits.ConfPerHour
+	return l
+}
+
+//WithLimiter sets the Limiter used to throttle
+//requests of an API allocated with NewAPI.
+func WithLimiter(l *Limiter) Option {
+	return func(c *config) {
+		c.limiter = l
+	}
+}
+
+//Remaining returns the budget left under each limit at this
+//moment. Limits that aren't enforced are reported as -1.
+//Hooks and PayFwds are not tracked and are always 0.
+func (l *Limiter) Remaining() (left Usage) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.prune(now)
+	remain := func(limit, hits int) int {
+		if limit <= 0 {
+			return -1
+		}
+		if hits > limit {
+			return 0
+		}
+		return limit - hits
+	}
+	for _, w := range []*window{&l.hour, &l.day, &l.hooks, &l.conf} {
+		w.roll(now)
+	}
+	left.PerSec = remain(l.limits.PerSec, len(l.recent))
+	left.PerHour = remain(l.limits.PerHour, l.hour.hits)
+	left.PerDay = remain(l.limits.PerDay, l.day.hits)
+	left.HooksPerHour = remain(l.limits.HooksPerHour, l.hooks.hits)
+	left.ConfPerHour = remain(l.limits.ConfPerHour, l.conf.hits)
+	return
+}
+
+//wait blocks until a request of kind can be made within
+//the limits, then counts it. It returns early with ctx's
+//error if ctx is done first.
+func (l *Limiter) wait(ctx context.Context, kind limitKind) error {
+	for {
+		d := l.reserve(kind)
+		if d <= 0 {
+			return nil
+		}
+		if err := sleepCtx(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+//reserve counts a request of kind and returns 0 if the
+//limits allow it now, or else returns how long to wait
+//before trying again.
+func (l *Limiter) reserve(kind limitKind) (d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	l.prune(now)
+	if l.limits.PerSec > 0 && len(l.recent) >= l.limits.PerSec {
+		d = l.recent[0].Add(time.Second).Sub(now)
+	}
+	waits := []time.Duration{
+		l.hour.wait(now, l.limits.PerHour),
+		l.day.wait(now, l.limits.PerDay),
+	}
+	switch kind {
+	case limitHook:
+		waits = append(waits, l.hooks.wait(now, l.limits.HooksPerHour))
+	case limitConf:
+		waits = append(waits, l.conf.wait(now, l.limits.ConfPerHour))
+	}
+	for _, w := range waits {
+		if w > d {
+			d = w
+		}
+	}
+	if d > 0 {
+		return
+	}
+	l.recent = append(l.recent, now)
+	l.hour.hits++
+	l.day.hits++
+	switch kind {
+	case limitHook:
+		l.hooks.hits++
+	case limitConf:
+		l.conf.hits++
+	}
+	return
+}
+
+//prune drops request times older than a second.
+func (l *Limiter) prune(now time.Time) {
+	i := 0
+	for i < len(l.recent) && now.Sub(l.recent[i]) >= time.Second {
+		i++
+	}
+	l.recent = l.recent[i:]
+}
+
+//limiter returns the configured Limiter, or nil.
+func (api *API) limiter() *Limiter {
+	if api.conf == nil {
+		return nil
+	}
+	return api.conf.limiter
+}
+
+//limitKindOf classifies a request by the
+//limits it counts against.
+func limitKindOf(method string, target *url.URL) limitKind {
+	switch {
+	case method == "POST" && strings.HasSuffix(target.Path, "/hooks"):
+		return limitHook
+	case strings.HasSuffix(target.Path, "/confidence"),
+		target.Query().Get("includeConfidence") == "true":
+		return limitConf
+	}
+	return limitAPI
+}
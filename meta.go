@@ -1,6 +1,7 @@
 package gobcy
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 )
@@ -15,6 +16,12 @@ import (
 //If private is true, will retrieve privately stored metadata
 //associated with your token.
 func (api *API) GetMeta(hash string, kind string, private bool) (meta map[string]string, err error) {
+	return api.GetMetaCtx(context.Background(), hash, kind, private)
+}
+
+//GetMetaCtx is like GetMeta, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetMetaCtx(ctx context.Context, hash string, kind string, private bool) (meta map[string]string, err error) {
 	if kind != "addr" && kind != "tx" && kind != "block" {
 		err = fmt.Errorf("Func GetMeta: kind an invalid type: '%v'. Needs to be 'addr', 'tx', or 'block'", kind)
 		return
@@ -24,7 +31,7 @@ func (api *API) GetMeta(hash string, kind string, private bool) (meta map[string
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &meta)
+	err = api.getResponse(ctx, u, &meta)
 	return
 }
 
@@ -38,6 +45,12 @@ func (api *API) GetMeta(hash string, kind string, private bool) (meta map[string
 //If private is true, will set privately stored metadata
 //associated with your token.
 func (api *API) PutMeta(hash string, kind string, private bool, meta map[string]string) (err error) {
+	return api.PutMetaCtx(context.Background(), hash, kind, private, meta)
+}
+
+//PutMetaCtx is like PutMeta, but uses ctx
+//for the underlying HTTP request.
+func (api *API) PutMetaCtx(ctx context.Context, hash string, kind string, private bool, meta map[string]string) (err error) {
 	if kind != "addr" && kind != "tx" && kind != "block" {
 		err = fmt.Errorf("Func PutMeta: kind an invalid type: '%v'. Needs to be 'addr', 'tx', or 'block'", kind)
 		return
@@ -47,7 +60,7 @@ func (api *API) PutMeta(hash string, kind string, private bool, meta map[string]
 	if err != nil {
 		return
 	}
-	err = putResponse(u, &meta)
+	err = api.putResponse(ctx, u, &meta)
 	return
 }
 
@@ -59,6 +72,12 @@ func (api *API) PutMeta(hash string, kind string, private bool, meta map[string]
 //  "block" (for a block)
 //Public metadata cannot be deleted; it is immutable.
 func (api *API) DeleteMeta(hash string, kind string) (err error) {
+	return api.DeleteMetaCtx(context.Background(), hash, kind)
+}
+
+//DeleteMetaCtx is like DeleteMeta, but uses ctx
+//for the underlying HTTP request.
+func (api *API) DeleteMetaCtx(ctx context.Context, hash string, kind string) (err error) {
 	if kind != "addr" && kind != "tx" && kind != "block" {
 		err = fmt.Errorf("Func DeleteMeta: kind an invalid type: '%v'. Needs to be 'addr', 'tx', or 'block'", kind)
 		return
@@ -67,6 +86,6 @@ func (api *API) DeleteMeta(hash string, kind string) (err error) {
 	if err != nil {
 		return
 	}
-	err = deleteResponse(u)
+	err = api.deleteResponse(ctx, u)
 	return
 }
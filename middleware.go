@@ -0,0 +1,128 @@
+package gobcy
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+//Call describes a single HTTP request to the BlockCypher API,
+//as seen by Middleware.
+type Call struct {
+	//Method is the HTTP method, e.g. "GET".
+	Method string
+	//Endpoint is the path template of the request relative
+	//to the coin/chain, with identifiers replaced by
+	//placeholders, e.g. "/addrs/{addr}/balance".
+	Endpoint string
+	//Coin and Chain are those of the API making the call.
+	Coin, Chain string
+	//URL is the full request URL with the token redacted.
+	URL string
+	//Header holds extra headers to send with the request;
+	//Middleware may add to it, e.g. for tracing.
+	Header http.Header
+	//Attempt counts from 1, and increases when
+	//the request is retried under a RetryPolicy.
+	Attempt int
+}
+
+//CallResult describes the outcome of a Call.
+type CallResult struct {
+	//StatusCode is the HTTP status of the response,
+	//or 0 if no response was received.
+	StatusCode int
+	//Latency is the time taken to send the request
+	//and read its response.
+	Latency time.Duration
+	//Err is the error the Call will return, if any.
+	Err error
+}
+
+//Invoker performs a Call.
+type Invoker func(ctx context.Context, c *Call) CallResult
+
+//Middleware wraps an Invoker to observe or alter every request made
+//by an API, for logging, metrics, tracing or auditing. For example:
+//	logCalls := func(next gobcy.Invoker) gobcy.Invoker {
+//		return func(ctx context.Context, c *gobcy.Call) gobcy.CallResult {
+//			res := next(ctx, c)
+//			log.Println(c.Method, c.Endpoint, res.StatusCode, res.Latency, res.Err)
+//			return res
+//		}
+//	}
+type Middleware func(next Invoker) Invoker
+
+//WithMiddleware appends Middleware to an API allocated with NewAPI.
+//The first Middleware given is the outermost, seeing each Call first.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *config) {
+		c.mw = append(c.mw, mw...)
+	}
+}
+
+//invoker wraps inv in the configured Middleware.
+func (api *API) invoker(inv Invoker) Invoker {
+	if api.conf == nil {
+		return inv
+	}
+	for i := len(api.conf.mw) - 1; i >= 0; i-- {
+		inv = api.conf.mw[i](inv)
+	}
+	return inv
+}
+
+//endpointParams maps a path segment to the placeholder of the
+//identifier following it, and literalSegments lists the segments
+//that may follow it without being an identifier.
+var (
+	endpointParams = map[string]string{
+		"addrs":    "{addr}",
+		"txs":      "{hash}",
+		"blocks":   "{block}",
+		"wallets":  "{name}",
+		"hd":       "{name}",
+		"hooks":    "{id}",
+		"payments": "{id}",
+		"oap":      "{assetid}",
+		"tokens":   "{token}",
+	}
+	literalSegments = map[string][]string{
+		"txs":     {"new", "send", "push", "decode"},
+		"wallets": {"hd"},
+		"oap":     {"addrs", "issue"},
+	}
+)
+
+//endpointOf returns the path template of target,
+//relative to the API's coin/chain.
+func (api *API) endpointOf(target *url.URL) string {
+	path := target.Path
+	if base, err := url.Parse(api.base()); err == nil {
+		path = strings.TrimPrefix(path, base.Path)
+	}
+	path = strings.TrimPrefix(path, "/")
+	path = strings.TrimPrefix(path, api.Coin+"/"+api.Chain)
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	prev := ""
+	for i, seg := range segs {
+		if ph, ok := endpointParams[prev]; ok && !isLiteral(prev, seg) {
+			segs[i] = ph
+			prev = ""
+			continue
+		}
+		prev = seg
+	}
+	return "/" + strings.Join(segs, "/")
+}
+
+func isLiteral(prev, seg string) bool {
+	for _, l := range literalSegments[prev] {
+		if seg == l {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,41 @@
+package gobcy
+
+import (
+	"errors"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+//ChainParams returns the address and key encoding parameters
+//of a coin/chain, as needed to decode addresses and build raw
+//transactions locally. Supported are "btc/main", "btc/test3",
+//"bcy/test", "ltc/main" and "doge/main".
+func ChainParams(coin, chain string) (params *chaincfg.Params, err error) {
+	switch coin + "/" + chain {
+	case "btc/main":
+		params = &chaincfg.MainNetParams
+	case "btc/test3":
+		params = &chaincfg.TestNet3Params
+	case "bcy/test":
+		params = withIDs("bcy", 0x1b, 0x1f, 0x49, "bcy")
+	case "ltc/main":
+		params = withIDs("ltc", 0x30, 0x32, 0xb0, "ltc")
+	case "doge/main":
+		params = withIDs("doge", 0x1e, 0x16, 0x9e, "")
+	default:
+		err = errors.New("Func ChainParams: unsupported coin/chain " + coin + "/" + chain)
+	}
+	return
+}
+
+//withIDs copies the Bitcoin main network parameters
+//with another coin's address and key prefixes.
+func withIDs(name string, pubKeyHash, scriptHash, privKey byte, hrp string) *chaincfg.Params {
+	params := chaincfg.MainNetParams
+	params.Name = name
+	params.PubKeyHashAddrID = pubKeyHash
+	params.ScriptHashAddrID = scriptHash
+	params.PrivateKeyID = privKey
+	params.Bech32HRPSegwit = hrp
+	return &params
+}
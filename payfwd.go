@@ -1,27 +1,42 @@
 package gobcy
 
-import "strconv"
+import (
+	"context"
+	"strconv"
+)
 
 //CreatePayFwd creates a new PayFwd forwarding
 //request associated with your API.Token, and
 //returns a PayFwd with a BlockCypher-assigned id.
 func (api *API) CreatePayFwd(payment PayFwd) (result PayFwd, err error) {
+	return api.CreatePayFwdCtx(context.Background(), payment)
+}
+
+//CreatePayFwdCtx is like CreatePayFwd, but uses ctx
+//for the underlying HTTP request.
+func (api *API) CreatePayFwdCtx(ctx context.Context, payment PayFwd) (result PayFwd, err error) {
 	u, err := api.buildURL("/payments", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &payment, &result)
+	err = api.postResponse(ctx, u, &payment, &result)
 	return
 }
 
 //ListPayFwds returns a PayFwds slice
 //associated with your API.Token.
 func (api *API) ListPayFwds() (payments []PayFwd, err error) {
+	return api.ListPayFwdsCtx(context.Background())
+}
+
+//ListPayFwdsCtx is like ListPayFwds, but uses ctx
+//for the underlying HTTP request.
+func (api *API) ListPayFwdsCtx(ctx context.Context) (payments []PayFwd, err error) {
 	u, err := api.buildURL("/payments", nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &payments)
+	err = api.getResponse(ctx, u, &payments)
 	return
 }
 
@@ -29,32 +44,50 @@ func (api *API) ListPayFwds() (payments []PayFwd, err error) {
 //associated with your API.Token, starting at the start index.
 //Useful for paging past the 200 payment forward limit.
 func (api *API) ListPayFwdsPage(start int) (payments []PayFwd, err error) {
+	return api.ListPayFwdsPageCtx(context.Background(), start)
+}
+
+//ListPayFwdsPageCtx is like ListPayFwdsPage, but uses ctx
+//for the underlying HTTP request.
+func (api *API) ListPayFwdsPageCtx(ctx context.Context, start int) (payments []PayFwd, err error) {
 	params := map[string]string{"start": strconv.Itoa(start)}
 	u, err := api.buildURL("/payments", params)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &payments)
+	err = api.getResponse(ctx, u, &payments)
 	return
 }
 
 //GetPayFwd returns a PayFwd based on its id.
 func (api *API) GetPayFwd(id string) (payment PayFwd, err error) {
+	return api.GetPayFwdCtx(context.Background(), id)
+}
+
+//GetPayFwdCtx is like GetPayFwd, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetPayFwdCtx(ctx context.Context, id string) (payment PayFwd, err error) {
 	u, err := api.buildURL("/payments/"+id, nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &payment)
+	err = api.getResponse(ctx, u, &payment)
 	return
 }
 
 //DeletePayFwd deletes a PayFwd request from
 //BlockCypher's database, based on its id.
 func (api *API) DeletePayFwd(id string) (err error) {
+	return api.DeletePayFwdCtx(context.Background(), id)
+}
+
+//DeletePayFwdCtx is like DeletePayFwd, but uses ctx
+//for the underlying HTTP request.
+func (api *API) DeletePayFwdCtx(ctx context.Context, id string) (err error) {
 	u, err := api.buildURL("/payments/"+id, nil)
 	if err != nil {
 		return
 	}
-	err = deleteResponse(u)
+	err = api.deleteResponse(ctx, u)
 	return
 }
@@ -0,0 +1,286 @@
+package gobcy
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+//KeyOrigin is the BIP-32 origin of a public key, used for
+//the derivation paths of a PSBT: the fingerprint of the
+//master key it derives from, and its derivation path.
+type KeyOrigin struct {
+	PubKey      string   `json:"pubkey"`
+	Fingerprint uint32   `json:"fingerprint"`
+	Path        []uint32 `json:"path"`
+}
+
+//NewPSBT converts a TXSkel returned by NewTX into a BIP-174
+//PSBT, for signers that speak PSBT rather than TXSkel, like
+//hardware wallets. Each input carries its previous TX, and
+//segwit inputs also their previous output, so NewPSBT fetches
+//them; P2SH multisig inputs carry their redeem script. The
+//inputs and outputs whose keys are in origins also carry
+//their derivation paths. The TX is rebuilt from Trans and
+//checked against ToSign; P2SH-P2WPKH inputs can only be
+//checked, and signed, if origins has their keys. Serialize
+//it with B64Encode, and once signed, send it with PushPSBT.
+func (api *API) NewPSBT(skel TXSkel, origins []KeyOrigin) (packet *psbt.Packet, err error) {
+	return api.NewPSBTCtx(context.Background(), skel, origins)
+}
+
+//NewPSBTCtx is like NewPSBT, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) NewPSBTCtx(ctx context.Context, skel TXSkel, origins []KeyOrigin) (packet *psbt.Packet, err error) {
+	params, err := ChainParams(api.Coin, api.Chain)
+	if err != nil {
+		return
+	}
+	if err = skel.checkSlots(); err != nil {
+		return
+	}
+	ver := int32(skel.Trans.Ver)
+	if ver == 0 {
+		ver = wire.TxVersion
+	}
+	msg := wire.NewMsgTx(ver)
+	msg.LockTime = uint32(skel.Trans.LockTime)
+	var prevs []rawPrev
+	for i, in := range skel.Trans.Inputs {
+		hash, err := chainhash.NewHashFromStr(in.PrevHash)
+		if err != nil {
+			return nil, err
+		}
+		txIn := wire.NewTxIn(wire.NewOutPoint(hash, uint32(in.OutputIndex)), nil, nil)
+		if in.Sequence != 0 {
+			txIn.Sequence = uint32(in.Sequence)
+		}
+		msg.AddTxIn(txIn)
+		prev := rawPrev{value: int64(in.OutputValue)}
+		if strings.HasPrefix(in.ScriptType, "multisig-") {
+			prev.redeem, err = multisigRedeem(in.Addresses, in.ScriptType, params)
+		}
+		if err == nil {
+			prev.script, err = scriptOf(in.Addresses, in.ScriptType, "", "", params)
+		}
+		if err != nil {
+			return nil, errors.New("Func NewPSBT: input " + strconv.Itoa(i) + ": " + err.Error())
+		}
+		prevs = append(prevs, prev)
+	}
+	outs, err := skel.outputs()
+	if err != nil {
+		return
+	}
+	for _, out := range outs {
+		msg.AddTxOut(out)
+	}
+	pubs, _, err := originKeys(origins)
+	if err != nil {
+		return
+	}
+	if err = checkSigHashes(msg, prevs, skel.ToSign, skel.starts(), pubs); err != nil {
+		return
+	}
+	return api.newPSBT(ctx, msg, prevs, origins)
+}
+
+//NewRawTXPSBT is like NewPSBT, but converts a RawTX,
+//without any signatures it already has.
+func (api *API) NewRawTXPSBT(raw *RawTX, origins []KeyOrigin) (packet *psbt.Packet, err error) {
+	return api.NewRawTXPSBTCtx(context.Background(), raw, origins)
+}
+
+//NewRawTXPSBTCtx is like NewRawTXPSBT, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) NewRawTXPSBTCtx(ctx context.Context, raw *RawTX, origins []KeyOrigin) (packet *psbt.Packet, err error) {
+	msg := raw.msg.Copy()
+	for _, in := range msg.TxIn {
+		in.SignatureScript, in.Witness = nil, nil
+	}
+	return api.newPSBT(ctx, msg, raw.prevs, origins)
+}
+
+//checkSigHashes checks that msg, spending prevs, has the
+//sighashes tosign, whose entries for input i start at starts[i].
+//The witness programs of P2SH-P2WPKH inputs are found from
+//their keys among pubs.
+func checkSigHashes(msg *wire.MsgTx, prevs []rawPrev, tosign []string, starts []int, pubs [][]byte) error {
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for i, in := range msg.TxIn {
+		fetcher.AddPrevOut(in.PreviousOutPoint, wire.NewTxOut(prevs[i].value, prevs[i].script))
+	}
+	hashes := txscript.NewTxSigHashes(msg, fetcher)
+	for i, prev := range prevs {
+		var hash []byte
+		var err error
+		switch {
+		case prev.redeem != nil:
+			hash, err = txscript.CalcSignatureHash(prev.redeem, txscript.SigHashAll, msg, i)
+		case txscript.IsPayToPubKeyHash(prev.script):
+			hash, err = txscript.CalcSignatureHash(prev.script, txscript.SigHashAll, msg, i)
+		case txscript.IsPayToWitnessPubKeyHash(prev.script):
+			hash, err = txscript.CalcWitnessSigHash(prev.script, hashes, txscript.SigHashAll, msg, i, prev.value)
+		case txscript.IsPayToScriptHash(prev.script):
+			//a P2SH input without pubkeys must be P2SH-P2WPKH
+			if _, program := scriptKeys(prev.script, nil, pubs); program != nil {
+				hash, err = txscript.CalcWitnessSigHash(program, hashes, txscript.SigHashAll, msg, i, prev.value)
+			} else {
+				err = errors.New("no KeyOrigin for the key of P2SH-P2WPKH input")
+			}
+		default:
+			err = errors.New("unsupported script type")
+		}
+		if err != nil {
+			return errors.New("Func NewPSBT: input " + strconv.Itoa(i) + ": " + err.Error())
+		}
+		if hex.EncodeToString(hash) != tosign[starts[i]] {
+			return errors.New("Func NewPSBT: input " + strconv.Itoa(i) + " doesn't match its ToSign")
+		}
+	}
+	return nil
+}
+
+//newPSBT returns the PSBT of the unsigned msg spending prevs,
+//fetching their previous TXs.
+func (api *API) newPSBT(ctx context.Context, msg *wire.MsgTx, prevs []rawPrev, origins []KeyOrigin) (packet *psbt.Packet, err error) {
+	if packet, err = psbt.NewFromUnsignedTx(msg); err != nil {
+		return
+	}
+	pubs, derivs, err := originKeys(origins)
+	if err != nil {
+		return
+	}
+	for i, in := range msg.TxIn {
+		prev, pin := prevs[i], &packet.Inputs[i]
+		pin.SighashType = txscript.SigHashAll
+		if pin.NonWitnessUtxo, err = api.prevTX(ctx, in.PreviousOutPoint, prev); err != nil {
+			return
+		}
+		keys, program := scriptKeys(prev.script, prev.redeem, pubs)
+		switch {
+		case prev.redeem != nil:
+			pin.RedeemScript = prev.redeem
+		case program != nil:
+			pin.RedeemScript = program
+			pin.WitnessUtxo = wire.NewTxOut(prev.value, prev.script)
+		case txscript.IsPayToWitnessPubKeyHash(prev.script):
+			pin.WitnessUtxo = wire.NewTxOut(prev.value, prev.script)
+		}
+		for _, k := range keys {
+			pin.Bip32Derivation = append(pin.Bip32Derivation, derivs[string(k)])
+		}
+	}
+	for j, out := range msg.TxOut {
+		keys, _ := scriptKeys(out.PkScript, nil, pubs)
+		for _, k := range keys {
+			packet.Outputs[j].Bip32Derivation = append(packet.Outputs[j].Bip32Derivation, derivs[string(k)])
+		}
+	}
+	return
+}
+
+//originKeys returns the pubkeys of origins, and
+//their PSBT derivations by serialized pubkey.
+func originKeys(origins []KeyOrigin) (pubs [][]byte, derivs map[string]*psbt.Bip32Derivation, err error) {
+	derivs = make(map[string]*psbt.Bip32Derivation)
+	for _, o := range origins {
+		pub, err := hex.DecodeString(o.PubKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		pubs = append(pubs, pub)
+		derivs[string(pub)] = &psbt.Bip32Derivation{PubKey: pub, MasterKeyFingerprint: o.Fingerprint, Bip32Path: o.Path}
+	}
+	return
+}
+
+//prevTX fetches the TX with the output op points to,
+//checking that output is prev.
+func (api *API) prevTX(ctx context.Context, op wire.OutPoint, prev rawPrev) (tx *wire.MsgTx, err error) {
+	trans, err := api.GetTXCtx(ctx, op.Hash.String(), map[string]string{"includeHex": "true"})
+	if err != nil {
+		return
+	}
+	raw, err := hex.DecodeString(trans.Hex)
+	if err != nil {
+		return
+	}
+	tx = wire.NewMsgTx(wire.TxVersion)
+	if err = tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return
+	}
+	if tx.TxHash() != op.Hash || int(op.Index) >= len(tx.TxOut) ||
+		tx.TxOut[op.Index].Value != prev.value || !bytes.Equal(tx.TxOut[op.Index].PkScript, prev.script) {
+		err = errors.New("Func NewPSBT: the previous TX of " + op.String() + " doesn't match the input")
+	}
+	return
+}
+
+//scriptKeys returns the keys of pubs that script, or the
+//multisig redeem script if set, pays to. If script is
+//P2SH-P2WPKH, it also returns its redeem script.
+func scriptKeys(script, redeem []byte, pubs [][]byte) (keys [][]byte, program []byte) {
+	if redeem != nil {
+		pushes, _ := txscript.PushedData(redeem)
+		for _, push := range pushes {
+			for _, pub := range pubs {
+				if bytes.Equal(push, pub) {
+					keys = append(keys, pub)
+				}
+			}
+		}
+		return
+	}
+	var hash []byte
+	switch txscript.GetScriptClass(script) {
+	case txscript.PubKeyHashTy:
+		hash = script[3:23]
+	case txscript.WitnessV0PubKeyHashTy, txscript.ScriptHashTy:
+		hash = script[2:22]
+	}
+	for _, pub := range pubs {
+		keyHash := btcutil.Hash160(pub)
+		p2wpkh := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, keyHash...)
+		if bytes.Equal(hash, keyHash) {
+			keys = append(keys, pub)
+		} else if txscript.IsPayToScriptHash(script) && bytes.Equal(hash, btcutil.Hash160(p2wpkh)) {
+			keys, program = append(keys, pub), p2wpkh
+		}
+	}
+	return
+}
+
+//PushPSBT finalizes a fully signed PSBT, like one made by
+//NewPSBT or NewRawTXPSBT and signed elsewhere, and pushes
+//the resulting transaction to the Coin/Chain network with
+//PushTX. Parse a base64-encoded PSBT with psbt.NewFromRawBytes.
+func (api *API) PushPSBT(packet *psbt.Packet) (trans TXSkel, err error) {
+	return api.PushPSBTCtx(context.Background(), packet)
+}
+
+//PushPSBTCtx is like PushPSBT, but uses ctx
+//for the underlying HTTP request.
+func (api *API) PushPSBTCtx(ctx context.Context, packet *psbt.Packet) (trans TXSkel, err error) {
+	if err = psbt.MaybeFinalizeAll(packet); err != nil {
+		return
+	}
+	tx, err := psbt.Extract(packet)
+	if err != nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err = tx.Serialize(&buf); err != nil {
+		return
+	}
+	return api.PushTXCtx(ctx, hex.EncodeToString(buf.Bytes()))
+}
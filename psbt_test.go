@@ -0,0 +1,208 @@
+package gobcy_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestPSBT(t *testing.T) {
+	srv := gobcytest.NewServer("btc", "test3")
+	defer srv.Close()
+	bc := srv.API("token")
+	params := &chaincfg.TestNet3Params
+	var privs []*btcec.PrivateKey
+	var pubs [][]byte
+	var origins []gobcy.KeyOrigin
+	for i := 0; i < 3; i++ {
+		priv, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub := priv.PubKey().SerializeCompressed()
+		privs, pubs = append(privs, priv), append(pubs, pub)
+		origins = append(origins, gobcy.KeyOrigin{PubKey: hex.EncodeToString(pub), Fingerprint: 0xdeadbeef,
+			Path: []uint32{hdkeychain.HardenedKeyStart + 84, hdkeychain.HardenedKeyStart + 1, hdkeychain.HardenedKeyStart, 0, uint32(i)}})
+	}
+	p2pkh, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubs[0]), params)
+	p2wpkh, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubs[1]), params)
+	srv.Fund(p2pkh.String(), 1e5)
+	srv.Fund(p2wpkh.String(), 1e5)
+	srv.Mine()
+	dest, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	//sign every input of a PSBT, as a hardware wallet would
+	sign := func(packet *psbt.Packet, keys map[int][]*btcec.PrivateKey) {
+		fetcher := txscript.NewMultiPrevOutFetcher(nil)
+		for i, in := range packet.UnsignedTx.TxIn {
+			prev := packet.Inputs[i].NonWitnessUtxo.TxOut[in.PreviousOutPoint.Index]
+			fetcher.AddPrevOut(in.PreviousOutPoint, prev)
+		}
+		hashes := txscript.NewTxSigHashes(packet.UnsignedTx, fetcher)
+		updater, _ := psbt.NewUpdater(packet)
+		for i, pin := range packet.Inputs {
+			for _, priv := range keys[i] {
+				var sig []byte
+				var err error
+				if pin.WitnessUtxo != nil {
+					script := pin.WitnessUtxo.PkScript
+					if pin.RedeemScript != nil {
+						script = pin.RedeemScript
+					}
+					sig, err = txscript.RawTxInWitnessSignature(packet.UnsignedTx, hashes, i, pin.WitnessUtxo.Value, script, txscript.SigHashAll, priv)
+				} else {
+					script := pin.RedeemScript
+					if script == nil {
+						script = pin.NonWitnessUtxo.TxOut[packet.UnsignedTx.TxIn[i].PreviousOutPoint.Index].PkScript
+					}
+					sig, err = txscript.RawTxInSignature(packet.UnsignedTx, i, script, txscript.SigHashAll, priv)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, err = updater.Sign(i, sig, priv.PubKey().SerializeCompressed(), pin.RedeemScript, nil); err != nil {
+					t.Fatal("Updater.Sign error encountered: ", err)
+				}
+			}
+		}
+	}
+	req := gobcy.TempNewTX(p2pkh.String(), dest.Address, *big.NewInt(15e4))
+	req.Inputs[0].Addresses = []string{p2pkh.String(), p2wpkh.String()}
+	skel, err := bc.NewTX(req, true)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	packet, err := bc.NewPSBT(skel, origins)
+	if err != nil {
+		t.Fatal("NewPSBT error encountered: ", err)
+	}
+	b64, err := packet.B64Encode()
+	if err != nil {
+		t.Fatal("B64Encode error encountered: ", err)
+	}
+	if packet, err = psbt.NewFromRawBytes(strings.NewReader(b64), true); err != nil {
+		t.Fatal("NewFromRawBytes error encountered: ", err)
+	}
+	keys := make(map[int][]*btcec.PrivateKey)
+	for i, pin := range packet.Inputs {
+		if pin.NonWitnessUtxo == nil || len(pin.Bip32Derivation) != 1 || pin.Bip32Derivation[0].MasterKeyFingerprint != 0xdeadbeef {
+			t.Fatalf("NewPSBT returned input %d without its previous TX or derivation", i)
+		}
+		for k, pub := range pubs {
+			if bytes.Equal(pin.Bip32Derivation[0].PubKey, pub) {
+				keys[i] = []*btcec.PrivateKey{privs[k]}
+				if (k == 1) != (pin.WitnessUtxo != nil) {
+					t.Errorf("NewPSBT returned input %d with witness UTXO %v", i, pin.WitnessUtxo)
+				}
+			}
+		}
+	}
+	if _, err = bc.PushPSBT(packet); err == nil {
+		t.Error("Expected error pushing an unsigned PSBT")
+	}
+	sign(packet, keys)
+	sent, err := bc.PushPSBT(packet)
+	if err != nil {
+		t.Fatal("PushPSBT error encountered: ", err)
+	}
+	if sent.Trans.Outputs[0].Value.Int64() != 15e4 {
+		t.Errorf("PushPSBT sent %v, expected 150000", &sent.Trans.Outputs[0].Value)
+	}
+	//a RawTX spending a 2-of-3 multisig
+	var msAddrs []*btcutil.AddressPubKey
+	var pubHex []string
+	for _, pub := range pubs {
+		addr, _ := btcutil.NewAddressPubKey(pub, params)
+		msAddrs, pubHex = append(msAddrs, addr), append(pubHex, hex.EncodeToString(pub))
+	}
+	redeem, _ := txscript.MultiSigScript(msAddrs, 2)
+	multisig, _ := btcutil.NewAddressScriptHash(redeem, params)
+	srv.Fund(multisig.String(), 1e5)
+	srv.Mine()
+	info, err := bc.GetAddr(multisig.String(), gobcy.AddrQuery{UnspentOnly: true, IncludeScript: true}.Params())
+	if err != nil || len(info.TXRefs) != 1 {
+		t.Fatalf("GetAddr returned %d refs, error %v", len(info.TXRefs), err)
+	}
+	raw, err := bc.NewRawTX()
+	if err != nil {
+		t.Fatal("NewRawTX error encountered: ", err)
+	}
+	if err = raw.AddMultisigInput(info.TXRefs[0], 2, pubHex); err != nil {
+		t.Fatal("AddMultisigInput error encountered: ", err)
+	}
+	if err = raw.AddOutput(p2wpkh.String(), *big.NewInt(9e4)); err != nil {
+		t.Fatal("AddOutput error encountered: ", err)
+	}
+	if packet, err = bc.NewRawTXPSBT(raw, origins); err != nil {
+		t.Fatal("NewRawTXPSBT error encountered: ", err)
+	}
+	if len(packet.Inputs[0].Bip32Derivation) != 3 || !bytes.Equal(packet.Inputs[0].RedeemScript, redeem) {
+		t.Error("NewRawTXPSBT returned a multisig input without its derivations or redeem script")
+	}
+	if len(packet.Outputs[0].Bip32Derivation) != 1 {
+		t.Error("NewRawTXPSBT returned an output to our key without its derivation")
+	}
+	sign(packet, map[int][]*btcec.PrivateKey{0: {privs[2], privs[0]}})
+	if _, err = bc.PushPSBT(packet); err != nil {
+		t.Fatal("PushPSBT error encountered: ", err)
+	}
+	//a TXSkel spending P2SH-P2WPKH, whose program
+	//only the KeyOrigin of its key gives away
+	program := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, btcutil.Hash160(pubs[2])...)
+	nested, _ := btcutil.NewAddressScriptHash(program, params)
+	srv.Fund(nested.String(), 1e5)
+	srv.Mine()
+	if info, err = bc.GetAddr(nested.String(), gobcy.AddrQuery{UnspentOnly: true}.Params()); err != nil || len(info.TXRefs) != 1 {
+		t.Fatalf("GetAddr returned %d refs, error %v", len(info.TXRefs), err)
+	}
+	ref := info.TXRefs[0]
+	hash, _ := chainhash.NewHashFromStr(ref.TXHash)
+	destAddr, _ := btcutil.DecodeAddress(dest.Address, params)
+	destScript, _ := txscript.PayToAddrScript(destAddr)
+	msg := wire.NewMsgTx(wire.TxVersion)
+	msg.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, uint32(ref.TXOutputN)), nil, nil))
+	msg.AddTxOut(wire.NewTxOut(9e4, destScript))
+	nestedScript, _ := txscript.PayToAddrScript(nested)
+	fetcher := txscript.NewCannedPrevOutputFetcher(nestedScript, 1e5)
+	tosign, err := txscript.CalcWitnessSigHash(program, txscript.NewTxSigHashes(msg, fetcher), txscript.SigHashAll, msg, 0, 1e5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	skel = gobcy.TXSkel{
+		Trans: gobcy.TX{
+			Ver: wire.TxVersion,
+			Inputs: []gobcy.TXInput{{PrevHash: ref.TXHash, OutputIndex: ref.TXOutputN, OutputValue: 1e5,
+				Addresses: []string{nested.String()}, ScriptType: "pay-to-script-hash", Sequence: int(wire.MaxTxInSequenceNum)}},
+			Outputs: []gobcy.TXOutput{{Value: *big.NewInt(9e4), Addresses: []string{dest.Address}, Script: hex.EncodeToString(destScript)}},
+		},
+		ToSign: []string{hex.EncodeToString(tosign)},
+	}
+	if _, err = bc.NewPSBT(skel, origins[:2]); err == nil {
+		t.Error("Expected error converting a P2SH-P2WPKH input without the KeyOrigin of its key")
+	}
+	if packet, err = bc.NewPSBT(skel, origins); err != nil {
+		t.Fatal("NewPSBT error encountered: ", err)
+	}
+	if !bytes.Equal(packet.Inputs[0].RedeemScript, program) || packet.Inputs[0].WitnessUtxo == nil {
+		t.Error("NewPSBT returned a P2SH-P2WPKH input without its witness program or UTXO")
+	}
+	sign(packet, map[int][]*btcec.PrivateKey{0: {privs[2]}})
+	if _, err = bc.PushPSBT(packet); err != nil {
+		t.Fatal("PushPSBT error encountered: ", err)
+	}
+}
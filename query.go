@@ -0,0 +1,118 @@
+package gobcy
+
+import "strconv"
+
+//AddrQuery holds the URL parameters accepted by the Address API
+//(GetAddr, GetAddrBal, GetAddrFull) and the Wallet API
+//(GetAddrWallet, GetAddrHDWallet). Use its Params method
+//wherever those take a params map:
+//	addr, err := bc.GetAddr(hash, gobcy.AddrQuery{UnspentOnly: true, Limit: 50}.Params())
+//Zero values are left out, so BlockCypher's defaults apply.
+type AddrQuery struct {
+	//Before and After filter by block height.
+	Before, After int
+	//Limit caps the number of TXRefs/TXs returned.
+	Limit int
+	//Confirmations only returns TXRefs/TXs with
+	//at least this many confirmations.
+	Confirmations int
+	//UnspentOnly only returns unspent TXRefs.
+	UnspentOnly bool
+	//IncludeScript includes raw scripts in TXRefs.
+	IncludeScript bool
+	//IncludeConfidence includes the confidence
+	//factor of unconfirmed TXRefs.
+	IncludeConfidence bool
+	//OmitWalletAddresses leaves out a Wallet's addresses
+	//when querying it in place of an address.
+	OmitWalletAddresses bool
+}
+
+//Params returns q as a URL parameter map.
+func (q AddrQuery) Params() map[string]string {
+	params := make(map[string]string)
+	setInt(params, "before", q.Before)
+	setInt(params, "after", q.After)
+	setInt(params, "limit", q.Limit)
+	setInt(params, "confirmations", q.Confirmations)
+	setBool(params, "unspentOnly", q.UnspentOnly)
+	setBool(params, "includeScript", q.IncludeScript)
+	setBool(params, "includeConfidence", q.IncludeConfidence)
+	setBool(params, "omitWalletAddresses", q.OmitWalletAddresses)
+	return params
+}
+
+//TXQuery holds the URL parameters accepted by GetTX.
+//Zero values are left out, so BlockCypher's defaults apply.
+type TXQuery struct {
+	//Limit caps the number of inputs and outputs returned.
+	Limit int
+	//InStart and OutStart are the index of the first
+	//input and output returned, for paging through them.
+	InStart, OutStart int
+	//IncludeHex includes the raw transaction in TX.Hex.
+	IncludeHex bool
+}
+
+//Params returns q as a URL parameter map.
+func (q TXQuery) Params() map[string]string {
+	params := make(map[string]string)
+	setInt(params, "limit", q.Limit)
+	setInt(params, "instart", q.InStart)
+	setInt(params, "outstart", q.OutStart)
+	setBool(params, "includeHex", q.IncludeHex)
+	return params
+}
+
+//BlockQuery holds the URL parameters accepted by GetBlock.
+//Zero values are left out, so BlockCypher's defaults apply.
+type BlockQuery struct {
+	//TXStart is the index of the first TXid returned,
+	//for paging through them.
+	TXStart int
+	//Limit caps the number of TXids returned.
+	Limit int
+}
+
+//Params returns q as a URL parameter map.
+func (q BlockQuery) Params() map[string]string {
+	params := make(map[string]string)
+	setInt(params, "txstart", q.TXStart)
+	setInt(params, "limit", q.Limit)
+	return params
+}
+
+//DeriveQuery holds the URL parameters accepted
+//by DeriveAddrHDWallet.
+type DeriveQuery struct {
+	//Count is the number of addresses to derive;
+	//BlockCypher derives one if it's 0.
+	Count int
+	//SubchainIndex selects the subchain to derive
+	//on; nil uses BlockCypher's default.
+	SubchainIndex *int
+}
+
+//Params returns q as a URL parameter map.
+func (q DeriveQuery) Params() map[string]string {
+	params := make(map[string]string)
+	setInt(params, "count", q.Count)
+	if q.SubchainIndex != nil {
+		params["subchain_index"] = strconv.Itoa(*q.SubchainIndex)
+	}
+	return params
+}
+
+//setInt sets params[k] if v is non-zero.
+func setInt(params map[string]string, k string, v int) {
+	if v != 0 {
+		params[k] = strconv.Itoa(v)
+	}
+}
+
+//setBool sets params[k] if v is true.
+func setBool(params map[string]string, k string, v bool) {
+	if v {
+		params[k] = "true"
+	}
+}
@@ -0,0 +1,365 @@
+package gobcy
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+//RawTX builds and signs a raw transaction locally, without
+//trusting BlockCypher's /txs/new endpoint. Add the UTXOs to
+//spend with AddInput or AddMultisigInput, the payments with
+//AddOutput, sign every input with Sign, then broadcast it
+//with PushTX:
+//	raw, err := bc.NewRawTX()
+//	err = raw.AddInput(ref)
+//	err = raw.AddOutput(dest, amount)
+//	err = raw.Sign(0, privHex)
+//	skel, err := bc.PushTX(raw.Hex())
+//Change is just another output, and the fee is whatever
+//the inputs hold beyond the outputs; see Fee.
+type RawTX struct {
+	params *chaincfg.Params
+	msg    *wire.MsgTx
+	prevs  []rawPrev
+}
+
+//rawPrev is the output spent by an input of a RawTX.
+type rawPrev struct {
+	script  []byte
+	value   int64
+	redeem  []byte
+	n       int
+	pubkeys [][]byte
+	sigs    map[int][]byte
+}
+
+//NewRawTX returns an empty RawTX for the API's Coin/Chain,
+//which must be one supported by ChainParams.
+func (api *API) NewRawTX() (raw *RawTX, err error) {
+	params, err := ChainParams(api.Coin, api.Chain)
+	if err != nil {
+		return
+	}
+	raw = &RawTX{params: params, msg: wire.NewMsgTx(wire.TxVersion)}
+	return
+}
+
+//AddInput adds an input spending the output ref points to,
+//which can be P2PKH, P2WPKH or P2SH-P2WPKH. ref needs the
+//output's Value and Script, so get it from GetAddr with
+//the "unspentOnly" and "includeScript" params set, e.g.:
+//	gobcy.AddrQuery{UnspentOnly: true, IncludeScript: true}.Params()
+//Use AddMultisigInput for P2SH multisig outputs.
+func (raw *RawTX) AddInput(ref TXRef) (err error) {
+	return raw.addInput(ref, nil, 0, nil)
+}
+
+//AddMultisigInput is like AddInput, but spends a P2SH
+//multisig output requiring n signatures of the hex-encoded
+//pubkeys, in the order they were used to make its address.
+func (raw *RawTX) AddMultisigInput(ref TXRef, n int, pubkeys []string) (err error) {
+	var keys [][]byte
+	var addrs []*btcutil.AddressPubKey
+	for _, k := range pubkeys {
+		pub, err := hex.DecodeString(k)
+		if err != nil {
+			return err
+		}
+		addr, err := btcutil.NewAddressPubKey(pub, raw.params)
+		if err != nil {
+			return err
+		}
+		keys = append(keys, pub)
+		addrs = append(addrs, addr)
+	}
+	redeem, err := txscript.MultiSigScript(addrs, n)
+	if err != nil {
+		return
+	}
+	return raw.addInput(ref, redeem, n, keys)
+}
+
+func (raw *RawTX) addInput(ref TXRef, redeem []byte, n int, pubkeys [][]byte) (err error) {
+	if ref.Script == "" {
+		err = errors.New("*RawTX.AddInput error: TXRef " + ref.TXHash + " has no Script, use the includeScript param")
+		return
+	}
+	script, err := hex.DecodeString(ref.Script)
+	if err != nil {
+		return
+	}
+	hash, err := chainhash.NewHashFromStr(ref.TXHash)
+	if err != nil {
+		return
+	}
+	class := txscript.GetScriptClass(script)
+	switch {
+	case redeem != nil && class != txscript.ScriptHashTy:
+		err = errors.New("*RawTX.AddInput error: multisig output of " + ref.TXHash + " isn't P2SH")
+	case redeem == nil && class != txscript.PubKeyHashTy && class != txscript.WitnessV0PubKeyHashTy && class != txscript.ScriptHashTy:
+		err = errors.New("*RawTX.AddInput error: unsupported script type " + class.String() + " in " + ref.TXHash)
+	case redeem != nil && !bytes.Equal(script[2:22], btcutil.Hash160(redeem)):
+		err = errors.New("*RawTX.AddInput error: pubkeys don't match the address of " + ref.TXHash)
+	}
+	if err != nil {
+		return
+	}
+	out := wire.NewOutPoint(hash, uint32(ref.TXOutputN))
+	raw.msg.AddTxIn(wire.NewTxIn(out, nil, nil))
+	raw.prevs = append(raw.prevs, rawPrev{script: script, value: ref.Value.Int64(),
+		redeem: redeem, n: n, pubkeys: pubkeys, sigs: make(map[int][]byte)})
+	return
+}
+
+//AddOutput adds an output paying value to addr.
+func (raw *RawTX) AddOutput(addr string, value big.Int) (err error) {
+	dest, err := btcutil.DecodeAddress(addr, raw.params)
+	if err != nil {
+		return
+	}
+	script, err := txscript.PayToAddrScript(dest)
+	if err != nil {
+		return
+	}
+	raw.msg.AddTxOut(wire.NewTxOut(value.Int64(), script))
+	return
+}
+
+//RBFSequence is the sequence of an input signaling that its
+//transaction may be replaced while unconfirmed (BIP-125),
+//such as by BumpFee. Set it with SetSequence.
+const RBFSequence = 0xfffffffd
+
+//SetSequence sets the sequence of input i, which is the
+//maximum, wire.MaxTxInSequenceNum, unless set otherwise.
+func (raw *RawTX) SetSequence(i int, seq uint32) (err error) {
+	if i < 0 || i >= len(raw.msg.TxIn) {
+		err = errors.New("*RawTX.SetSequence error: no input " + strconv.Itoa(i))
+		return
+	}
+	raw.msg.TxIn[i].Sequence = seq
+	return
+}
+
+//Fee returns the sum of the inputs' values minus the sum of
+//the outputs' values, which is what the transaction pays
+//miners.
+func (raw *RawTX) Fee() (fee big.Int) {
+	var sum int64
+	for _, prev := range raw.prevs {
+		sum += prev.value
+	}
+	for _, out := range raw.msg.TxOut {
+		sum -= out.Value
+	}
+	fee.SetInt64(sum)
+	return
+}
+
+//Sign signs input i with the hex-encoded private keys priv,
+//using SIGHASH_ALL. P2PKH, P2WPKH and P2SH-P2WPKH inputs
+//take one key, which signs for P2PKH addresses of either
+//form of its public key; multisig inputs take up to n, and
+//Sign can be called again to add more. Sign every input
+//only after adding every input and output, since
+//signatures commit to all of them.
+func (raw *RawTX) Sign(i int, priv ...string) (err error) {
+	if i < 0 || i >= len(raw.prevs) {
+		err = errors.New("*RawTX.Sign error: no input " + strconv.Itoa(i))
+		return
+	}
+	for j, k := range priv {
+		signer := NewKeySigner()
+		if signer.AddHex(k) != nil {
+			return errors.New("*RawTX.Sign error: private key " + strconv.Itoa(j) + " isn't hex-encoded")
+		}
+		pubs, _ := signer.PubKeys()
+		keys, _ := scriptKeys(raw.prevs[i].script, raw.prevs[i].redeem, pubs)
+		if len(keys) == 0 {
+			return errors.New("*RawTX.Sign error: key doesn't match input " + strconv.Itoa(i))
+		}
+		if err = raw.sign(i, signer, keys[0]); err != nil {
+			return err
+		}
+	}
+	return
+}
+
+//SignWith signs every input of a RawTX with the keys of
+//signer, like Sign: P2PKH, P2WPKH and P2SH-P2WPKH inputs with
+//the key of their address, and multisig inputs with as many
+//of their keys as signer holds, up to n. It fails, naming
+//them, if some inputs are left without all the signatures
+//they need: those signer has no key for are left unsigned,
+//and multisig inputs with too few keys partly signed.
+func (raw *RawTX) SignWith(signer Signer) (err error) {
+	pubs, err := signer.PubKeys()
+	if err != nil {
+		return
+	}
+	var missing []string
+	for i, prev := range raw.prevs {
+		var keys [][]byte
+		if prev.redeem != nil {
+			for _, k := range prev.pubkeys {
+				for _, p := range pubs {
+					if len(keys) < prev.n && bytes.Equal(k, p) {
+						keys = append(keys, p)
+					}
+				}
+			}
+		} else if keys, _ = scriptKeys(prev.script, nil, pubs); len(keys) > 1 {
+			keys = keys[:1]
+		}
+		for _, pub := range keys {
+			if err = raw.sign(i, signer, pub); err != nil {
+				return
+			}
+		}
+		have, need := len(keys), 1
+		if prev.redeem != nil {
+			//earlier calls to Sign may have added others
+			have, need = len(raw.prevs[i].sigs), prev.n
+		}
+		if have < need {
+			missing = append(missing, strconv.Itoa(i)+" ("+strconv.Itoa(have)+" of "+strconv.Itoa(need)+")")
+		}
+	}
+	if len(missing) > 0 {
+		err = errors.New("*RawTX.SignWith error: missing signatures for inputs " + strings.Join(missing, ", "))
+	}
+	return
+}
+
+func (raw *RawTX) sign(i int, signer Signer, pub []byte) (err error) {
+	prev := &raw.prevs[i]
+	in := raw.msg.TxIn[i]
+	mismatch := errors.New("*RawTX.Sign error: key doesn't match input " + strconv.Itoa(i))
+	if prev.redeem != nil {
+		n := -1
+		for j, k := range prev.pubkeys {
+			if bytes.Equal(k, pub) {
+				n = j
+			}
+		}
+		if n < 0 {
+			return mismatch
+		}
+		hash, err := txscript.CalcSignatureHash(prev.redeem, txscript.SigHashAll, raw.msg, i)
+		if err != nil {
+			return err
+		}
+		if prev.sigs[n], err = rawSig(signer, pub, hash); err != nil {
+			return err
+		}
+		in.SignatureScript, err = raw.multisigScript(prev)
+		return err
+	}
+	keyHash := btcutil.Hash160(pub)
+	switch txscript.GetScriptClass(prev.script) {
+	case txscript.PubKeyHashTy:
+		if !bytes.Equal(prev.script[3:23], keyHash) {
+			return mismatch
+		}
+		hash, err := txscript.CalcSignatureHash(prev.script, txscript.SigHashAll, raw.msg, i)
+		if err != nil {
+			return err
+		}
+		sig, err := rawSig(signer, pub, hash)
+		if err != nil {
+			return err
+		}
+		in.SignatureScript, err = txscript.NewScriptBuilder().AddData(sig).AddData(pub).Script()
+	case txscript.WitnessV0PubKeyHashTy:
+		if !bytes.Equal(prev.script[2:], keyHash) {
+			return mismatch
+		}
+		in.Witness, err = raw.witness(i, prev.script, signer, pub)
+	case txscript.ScriptHashTy:
+		//a P2SH output without pubkeys must be P2SH-P2WPKH,
+		//whose redeem script is the key's witness program
+		program, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(keyHash).Script()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(prev.script[2:22], btcutil.Hash160(program)) {
+			return mismatch
+		}
+		if in.Witness, err = raw.witness(i, program, signer, pub); err != nil {
+			return err
+		}
+		in.SignatureScript, err = txscript.NewScriptBuilder().AddData(program).Script()
+		return err
+	}
+	return
+}
+
+//witness returns the P2WPKH witness signing input i,
+//whose witness program is program.
+func (raw *RawTX) witness(i int, program []byte, signer Signer, pub []byte) (wire.TxWitness, error) {
+	if raw.params.Bech32HRPSegwit == "" {
+		return nil, errors.New("*RawTX.Sign error: " + raw.params.Name + " doesn't support segwit")
+	}
+	fetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for j, in := range raw.msg.TxIn {
+		fetcher.AddPrevOut(in.PreviousOutPoint, wire.NewTxOut(raw.prevs[j].value, raw.prevs[j].script))
+	}
+	hashes := txscript.NewTxSigHashes(raw.msg, fetcher)
+	hash, err := txscript.CalcWitnessSigHash(program, hashes, txscript.SigHashAll, raw.msg, i, raw.prevs[i].value)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := rawSig(signer, pub, hash)
+	if err != nil {
+		return nil, err
+	}
+	return wire.TxWitness{sig, pub}, nil
+}
+
+//rawSig returns the signature of hash by the key of
+//pub, with its SIGHASH_ALL type appended.
+func rawSig(signer Signer, pub, hash []byte) ([]byte, error) {
+	sig, err := signer.SignHash(pub, hash)
+	if err != nil {
+		return nil, err
+	}
+	return append(sig, byte(txscript.SigHashAll)), nil
+}
+
+//multisigScript returns the P2SH multisig signature script
+//of prev, with its signatures so far in pubkey order.
+func (raw *RawTX) multisigScript(prev *rawPrev) ([]byte, error) {
+	b := txscript.NewScriptBuilder().AddOp(txscript.OP_0)
+	added := 0
+	for j := range prev.pubkeys {
+		if sig, ok := prev.sigs[j]; ok && added < prev.n {
+			b.AddData(sig)
+			added++
+		}
+	}
+	return b.AddData(prev.redeem).Script()
+}
+
+//Hash returns the transaction's hash, which is
+//final once every input is signed.
+func (raw *RawTX) Hash() string {
+	return raw.msg.TxHash().String()
+}
+
+//Hex returns the hex-encoded raw transaction, ready for
+//PushTX once every input is signed.
+func (raw *RawTX) Hex() string {
+	var buf bytes.Buffer
+	raw.msg.Serialize(&buf)
+	return hex.EncodeToString(buf.Bytes())
+}
@@ -0,0 +1,126 @@
+package gobcy_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+func TestRawTX(t *testing.T) {
+	srv := gobcytest.NewServer("btc", "test3")
+	defer srv.Close()
+	bc := srv.API("token")
+	params := &chaincfg.TestNet3Params
+	var privs []*btcec.PrivateKey
+	var pubs []string
+	for i := 0; i < 3; i++ {
+		priv, err := btcec.NewPrivateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		privs = append(privs, priv)
+		pubs = append(pubs, hex.EncodeToString(priv.PubKey().SerializeCompressed()))
+	}
+	keyHash := btcutil.Hash160(privs[0].PubKey().SerializeCompressed())
+	program, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(keyHash).Script()
+	var msAddrs []*btcutil.AddressPubKey
+	for _, priv := range privs {
+		addr, _ := btcutil.NewAddressPubKey(priv.PubKey().SerializeCompressed(), params)
+		msAddrs = append(msAddrs, addr)
+	}
+	redeem, _ := txscript.MultiSigScript(msAddrs, 2)
+	p2pkh, _ := btcutil.NewAddressPubKeyHash(keyHash, params)
+	p2wpkh, _ := btcutil.NewAddressWitnessPubKeyHash(keyHash, params)
+	nested, _ := btcutil.NewAddressScriptHash(program, params)
+	multisig, _ := btcutil.NewAddressScriptHash(redeem, params)
+	uncompressed, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(privs[1].PubKey().SerializeUncompressed()), params)
+	from := []string{p2pkh.String(), p2wpkh.String(), nested.String(), multisig.String(), uncompressed.String()}
+	for _, addr := range from {
+		srv.Fund(addr, 1e5)
+	}
+	srv.Mine()
+	raw, err := bc.NewRawTX()
+	if err != nil {
+		t.Fatal("NewRawTX error encountered: ", err)
+	}
+	for _, addr := range from {
+		info, err := bc.GetAddr(addr, gobcy.AddrQuery{UnspentOnly: true, IncludeScript: true}.Params())
+		if err != nil || len(info.TXRefs) != 1 {
+			t.Fatalf("GetAddr of %s returned %d refs, error %v", addr, len(info.TXRefs), err)
+		}
+		if addr == multisig.String() {
+			err = raw.AddMultisigInput(info.TXRefs[0], 2, pubs)
+		} else {
+			err = raw.AddInput(info.TXRefs[0])
+		}
+		if err != nil {
+			t.Fatal("AddInput error encountered: ", err)
+		}
+	}
+	dest, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	if err = raw.AddOutput(dest.Address, *big.NewInt(49e4)); err != nil {
+		t.Fatal("AddOutput error encountered: ", err)
+	}
+	if fee := raw.Fee(); fee.Int64() != 1e4 {
+		t.Errorf("Fee returned %v, expected 10000", &fee)
+	}
+	privHex := func(i int) string {
+		return hex.EncodeToString(privs[i].Serialize())
+	}
+	if err = raw.Sign(0, privHex(1)); err == nil {
+		t.Error("Expected error signing an input with the wrong key")
+	}
+	//one key of a 2-of-3 multisig isn't enough
+	signer := gobcy.NewKeySigner()
+	signer.Add(privs[0], true)
+	if err = raw.SignWith(signer); err == nil || !strings.Contains(err.Error(), "3 (1 of 2)") {
+		t.Errorf("Expected error naming the partly signed multisig input, got: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err = raw.Sign(i, privHex(0)); err != nil {
+			t.Fatal("Sign error encountered: ", err)
+		}
+	}
+	if err = raw.Sign(3, privHex(0)); err != nil {
+		t.Fatal("Sign error encountered: ", err)
+	}
+	if _, err = bc.PushTX(raw.Hex()); err == nil {
+		t.Error("Expected error pushing a TX missing a multisig signature")
+	}
+	if err = raw.Sign(3, privHex(2)); err != nil {
+		t.Fatal("Sign error encountered: ", err)
+	}
+	if err = raw.Sign(4, privHex(1)[2:]); err == nil {
+		t.Error("Expected error signing with a key of the wrong length")
+	}
+	//hex keys sign for uncompressed addresses too
+	if err = raw.Sign(4, privHex(1)); err != nil {
+		t.Fatal("Sign error encountered: ", err)
+	}
+	skel, err := bc.PushTX(raw.Hex())
+	if err != nil {
+		t.Fatal("PushTX error encountered: ", err)
+	}
+	if skel.Trans.Hash != raw.Hash() {
+		t.Errorf("PushTX returned hash %s, expected %s", skel.Trans.Hash, raw.Hash())
+	}
+	srv.Mine()
+	bal, err := bc.GetAddrBal(dest.Address, nil)
+	if err != nil {
+		t.Fatal("GetAddrBal error encountered: ", err)
+	}
+	if bal.Balance.Int64() != 49e4 {
+		t.Errorf("GetAddrBal returned %v, expected 490000", &bal.Balance)
+	}
+}
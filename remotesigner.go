@@ -0,0 +1,152 @@
+package gobcy
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+)
+
+//signerRequest and signerResponse are the messages of
+//the protocol between RemoteSigner and ServeSigner,
+//sent as a stream of JSON objects.
+type signerRequest struct {
+	Method string `json:"method"`
+	PubKey string `json:"pubkey,omitempty"`
+	Hash   string `json:"hash,omitempty"`
+}
+
+type signerResponse struct {
+	PubKeys   []string `json:"pubkeys,omitempty"`
+	Signature string   `json:"signature,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+//RemoteSigner is a Signer whose keys are held by another
+//process, like a signing service serving them with
+//ServeSigner on a local socket. Private keys never leave
+//that process; only hashes and signatures are exchanged.
+type RemoteSigner struct {
+	mu   sync.Mutex
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+}
+
+//DialSigner connects to a signing process served by
+//ServeSigner, e.g. DialSigner("unix", "/run/signer.sock").
+func DialSigner(network, address string) (signer *RemoteSigner, err error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return
+	}
+	signer = &RemoteSigner{conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)}
+	return
+}
+
+//call sends req and waits for its response.
+func (rs *RemoteSigner) call(req signerRequest) (resp signerResponse, err error) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	if err = rs.enc.Encode(req); err != nil {
+		return
+	}
+	if err = rs.dec.Decode(&resp); err != nil {
+		return
+	}
+	if resp.Error != "" {
+		err = errors.New("*RemoteSigner error: " + resp.Error)
+	}
+	return
+}
+
+//PubKeys returns the public keys of the keys
+//held by the signing process.
+func (rs *RemoteSigner) PubKeys() (pubs [][]byte, err error) {
+	resp, err := rs.call(signerRequest{Method: "pubkeys"})
+	if err != nil {
+		return
+	}
+	for _, k := range resp.PubKeys {
+		pub, err := hex.DecodeString(k)
+		if err != nil {
+			return nil, err
+		}
+		pubs = append(pubs, pub)
+	}
+	return
+}
+
+//SignHash asks the signing process to sign hash
+//with the key of pubkey.
+func (rs *RemoteSigner) SignHash(pubkey, hash []byte) (sig []byte, err error) {
+	resp, err := rs.call(signerRequest{Method: "sign", PubKey: hex.EncodeToString(pubkey), Hash: hex.EncodeToString(hash)})
+	if err != nil {
+		return
+	}
+	return hex.DecodeString(resp.Signature)
+}
+
+//Close closes the connection to the signing process.
+func (rs *RemoteSigner) Close() error {
+	return rs.conn.Close()
+}
+
+//ServeSigner serves the keys of signer to RemoteSigners
+//connecting to l, until l is closed. Run it in the signing
+//process, on a socket only trusted processes can reach:
+//	l, err := net.Listen("unix", "/run/signer.sock")
+//	err = gobcy.ServeSigner(l, keystoreSigner)
+func ServeSigner(l net.Listener, signer Signer) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go serveSignerConn(conn, signer)
+	}
+}
+
+//serveSignerConn answers the requests of one
+//RemoteSigner until it disconnects.
+func serveSignerConn(conn net.Conn, signer Signer) {
+	defer conn.Close()
+	enc, dec := json.NewEncoder(conn), json.NewDecoder(conn)
+	for {
+		var req signerRequest
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+		var resp signerResponse
+		var err error
+		switch req.Method {
+		case "pubkeys":
+			var pubs [][]byte
+			if pubs, err = signer.PubKeys(); err == nil {
+				for _, pub := range pubs {
+					resp.PubKeys = append(resp.PubKeys, hex.EncodeToString(pub))
+				}
+			}
+		case "sign":
+			var pub, hash, sig []byte
+			if pub, err = hex.DecodeString(req.PubKey); err != nil {
+				break
+			}
+			if hash, err = hex.DecodeString(req.Hash); err != nil {
+				break
+			}
+			if sig, err = signer.SignHash(pub, hash); err == nil {
+				resp.Signature = hex.EncodeToString(sig)
+			}
+		default:
+			err = errors.New("unknown method " + req.Method)
+		}
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		if err = enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package gobcy
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+//RetryPolicy configures automatic retries of requests
+//that fail from rate limiting (HTTP 429), server errors
+//(HTTP 500, 502, 503, 504) or network errors. Idempotent
+//GET, PUT and DELETE calls are retried whenever MaxAttempts
+//is greater than 1; POSTs only as allowed below.
+//Between attempts the policy waits an exponentially growing,
+//jittered delay between MinBackoff and MaxBackoff, or the
+//Retry-After delay sent by BlockCypher if that is longer.
+type RetryPolicy struct {
+	//MaxAttempts is the total number of attempts per
+	//request, including the first. 0 or 1 disables retries.
+	MaxAttempts int
+	//MinBackoff is the delay before the first retry.
+	//Defaults to 500ms.
+	MinBackoff time.Duration
+	//MaxBackoff caps the delay between attempts.
+	//Defaults to 30s.
+	MaxBackoff time.Duration
+	//RetryPOST allows retrying POSTs without side effects
+	//on BlockCypher's end, like NewTX and DecodeTX.
+	RetryPOST bool
+	//RetryBroadcast allows retrying SendTX and PushTX. A
+	//failed attempt may still have reached the network, so
+	//a retry can be answered with an error about the
+	//transaction already existing.
+	RetryBroadcast bool
+}
+
+//WithRetry sets the RetryPolicy of an API allocated with NewAPI.
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *config) {
+		c.retry = policy
+	}
+}
+
+//retryClass describes whether a request
+//may be retried under a RetryPolicy.
+type retryClass int
+
+const (
+	//retryNever requests create resources and are never retried.
+	retryNever retryClass = iota
+	//retryIdempotent requests are always safe to retry.
+	retryIdempotent
+	//retryPOST requests are retried if RetryPOST is set.
+	retryPOST
+	//retryBroadcast requests are retried if RetryBroadcast is set.
+	retryBroadcast
+)
+
+//retryPolicy returns the configured RetryPolicy,
+//or the zero RetryPolicy if none was set.
+func (api *API) retryPolicy() RetryPolicy {
+	if api.conf == nil {
+		return RetryPolicy{}
+	}
+	return api.conf.retry
+}
+
+//next decides whether a request of the given class that
+//failed with err on the given attempt should be retried,
+//and if so how long to wait beforehand.
+func (p RetryPolicy) next(ctx context.Context, class retryClass, attempt int, err error) (wait time.Duration, retry bool) {
+	if err == nil || attempt >= p.MaxAttempts || ctx.Err() != nil {
+		return
+	}
+	switch class {
+	case retryNever:
+		return
+	case retryPOST:
+		if !p.RetryPOST {
+			return
+		}
+	case retryBroadcast:
+		if !p.RetryBroadcast {
+			return
+		}
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError,
+			http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		default:
+			return
+		}
+	}
+	wait = p.backoff(attempt)
+	if apiErr != nil && apiErr.RetryAfter > wait {
+		wait = apiErr.RetryAfter
+	}
+	retry = true
+	return
+}
+
+//backoff returns the jittered delay before the retry
+//following the given attempt.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	lo, hi := p.MinBackoff, p.MaxBackoff
+	if lo <= 0 {
+		lo = 500 * time.Millisecond
+	}
+	if hi <= 0 {
+		hi = 30 * time.Second
+	}
+	d := lo
+	for i := 1; i < attempt && d < hi; i++ {
+		d *= 2
+	}
+	if d > hi {
+		d = hi
+	}
+	//"equal jitter": half fixed, half random
+	half := d / 2
+	jitterMu.Lock()
+	j := time.Duration(jitterRand.Int63n(int64(half) + 1))
+	jitterMu.Unlock()
+	return half + j
+}
+
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+//sleepCtx waits for d, returning early
+//with ctx's error if it is done first.
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
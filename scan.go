@@ -0,0 +1,184 @@
+package gobcy
+
+import "context"
+
+//scanWindow is how many recent block hashes a Scanner
+//remembers, bounding the depth of reorgs it can place.
+const scanWindow = 500
+
+//Reorg describes a chain reorganization found by a Scanner.
+//Blocks above ForkHeight that were already returned are no longer
+//in the chain; their hashes are in Orphaned, newest first. ForkHash
+//is the hash at ForkHeight, or empty if the fork is below the blocks
+//the Scanner knows about.
+type Reorg struct {
+	ForkHeight int
+	ForkHash   string
+	Orphaned   []string
+}
+
+//Scanner walks the blocks of a chain in height order, checking
+//that each one builds on the last and reporting reorganizations.
+//Use it like so:
+//	sc := bc.ScanBlocks(from, to, "", nil)
+//	for sc.Next() {
+//		if r := sc.Reorg(); r != nil {
+//			//roll back everything above r.ForkHeight
+//		}
+//		block := sc.Block()
+//	}
+//	if err := sc.Err(); err != nil {
+//		...
+//	}
+type Scanner struct {
+	api     *API
+	ctx     context.Context
+	params  map[string]string
+	next    int
+	end     int
+	tip     int
+	hint    int
+	hashes  map[int]string
+	lowest  int
+	block   Block
+	reorg   *Reorg
+	pending *Reorg
+	err     error
+}
+
+//ScanBlocks returns a Scanner over the blocks from height from to
+//height to, inclusive. If to is negative, it follows the tip instead,
+//using GetChain: then Next returns false once it's caught up with the
+//tip, and can be called again later to pick up new blocks. If prevHash
+//isn't empty, it's the hash of the block at from-1, such as the last
+//one processed before a restart, and is checked like any other. The
+//params (see BlockQuery) are used to get every Block.
+func (api *API) ScanBlocks(from, to int, prevHash string, params map[string]string) *Scanner {
+	return api.ScanBlocksCtx(context.Background(), from, to, prevHash, params)
+}
+
+//ScanBlocksCtx is like ScanBlocks, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) ScanBlocksCtx(ctx context.Context, from, to int, prevHash string, params map[string]string) *Scanner {
+	s := &Scanner{api: api, ctx: ctx, params: params, next: from, end: to, tip: -1, hashes: make(map[int]string), lowest: from}
+	if prevHash != "" {
+		s.hashes[from-1] = prevHash
+		s.lowest = from - 1
+	}
+	return s
+}
+
+//Next advances to the next Block, returning false when the
+//range is done, the tip is reached or an error occurred; see Err.
+func (s *Scanner) Next() bool {
+	s.reorg = nil
+	for s.err == nil {
+		if s.end >= 0 && s.next > s.end {
+			return false
+		}
+		if s.end < 0 && s.next > s.tip {
+			chain, err := s.api.GetChainCtx(s.ctx)
+			if err != nil {
+				s.err = err
+				return false
+			}
+			s.tip, s.hint = chain.Height, chain.LastForkHeight
+			//the tip itself may have been replaced
+			if hash, found := s.hashes[chain.Height]; found && hash != chain.Hash {
+				s.err = s.rewind(chain.Height)
+				continue
+			}
+			if s.next > s.tip {
+				return false
+			}
+		}
+		block, err := s.api.GetBlockByIDCtx(s.ctx, ByHeight(s.next), s.params)
+		if err != nil {
+			s.err = err
+			return false
+		}
+		if prev, found := s.hashes[s.next-1]; found && block.PrevBlock != prev {
+			s.err = s.rewind(s.next - 1)
+			continue
+		}
+		s.hashes[block.Height] = block.Hash
+		delete(s.hashes, block.Height-scanWindow)
+		if s.lowest < block.Height-scanWindow+1 {
+			s.lowest = block.Height - scanWindow + 1
+		}
+		s.block, s.reorg, s.pending = block, s.pending, nil
+		s.next++
+		return true
+	}
+	return false
+}
+
+//rewind finds where the chain forked below the block
+//at height h, which is no longer in it, and moves the
+//Scanner back to the block after the fork.
+func (s *Scanner) rewind(h int) (err error) {
+	fork, err := s.findFork(h)
+	if err != nil {
+		return
+	}
+	r := &Reorg{ForkHeight: fork, ForkHash: s.hashes[fork]}
+	for k := s.next - 1; k > fork; k-- {
+		if hash, found := s.hashes[k]; found {
+			r.Orphaned = append(r.Orphaned, hash)
+			delete(s.hashes, k)
+		}
+	}
+	if s.pending != nil {
+		r.Orphaned = append(s.pending.Orphaned, r.Orphaned...)
+	}
+	s.pending = r
+	s.next = fork + 1
+	if s.lowest > fork+1 {
+		s.lowest = fork + 1
+	}
+	return
+}
+
+//findFork returns the highest height below h whose known
+//hash is still in the chain, or the height below the known
+//hashes if none is. It probes the height below h, or below
+//the chain's LastForkHeight, first, then bisects.
+func (s *Scanner) findFork(h int) (fork int, err error) {
+	//lo is assumed to match, hi known not to
+	lo, hi := s.lowest-1, h
+	probe := hi - 1
+	if s.hint > lo+1 && s.hint <= h {
+		probe = s.hint - 1
+	}
+	for lo+1 < hi {
+		var block Block
+		if block, err = s.api.GetBlockHeaderCtx(s.ctx, ByHeight(probe)); err != nil {
+			return
+		}
+		if block.Hash == s.hashes[probe] {
+			lo = probe
+		} else {
+			hi = probe
+		}
+		probe = (lo + hi) / 2
+	}
+	fork = lo
+	return
+}
+
+//Block returns the current Block.
+func (s *Scanner) Block() Block {
+	return s.block
+}
+
+//Reorg returns the reorganization found just before the current
+//Block, if any. The current Block is then the first one above
+//Reorg().ForkHeight in the new chain.
+func (s *Scanner) Reorg() *Reorg {
+	return s.reorg
+}
+
+//Err returns the error that stopped the Scanner, if any.
+func (s *Scanner) Err() error {
+	return s.err
+}
@@ -0,0 +1,71 @@
+package gobcy_test
+
+import (
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+)
+
+func TestScanBlocks(t *testing.T) {
+	srv := gobcytest.NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	for i := 0; i < 5; i++ {
+		srv.Mine()
+	}
+	hashes := make(map[int]string)
+	sc := bc.ScanBlocks(1, -1, "", nil)
+	for sc.Next() {
+		b := sc.Block()
+		if sc.Reorg() != nil {
+			t.Errorf("ScanBlocks reported a reorg at %d", b.Height)
+		}
+		hashes[b.Height] = b.Hash
+	}
+	if err := sc.Err(); err != nil || len(hashes) != 5 {
+		t.Fatalf("ScanBlocks returned %d blocks, %v; expected 5", len(hashes), err)
+	}
+	//replace blocks 4 and 5, then extend the chain
+	srv.Reorg(2)
+	for i := 0; i < 3; i++ {
+		srv.Mine()
+	}
+	if !sc.Next() {
+		t.Fatal("ScanBlocks error encountered: ", sc.Err())
+	}
+	r := sc.Reorg()
+	if r == nil || r.ForkHeight != 3 || r.ForkHash != hashes[3] || len(r.Orphaned) != 2 || r.Orphaned[0] != hashes[5] {
+		t.Fatalf("ScanBlocks reported reorg %+v, expected a fork at 3", r)
+	}
+	if b := sc.Block(); b.Height != 4 || b.Hash == hashes[4] {
+		t.Errorf("ScanBlocks returned block %d after the reorg, expected the new block 4", b.Height)
+	}
+	n := 0
+	for ; sc.Next(); n++ {
+		hashes[sc.Block().Height] = sc.Block().Hash
+	}
+	if sc.Err() != nil || n != 2 {
+		t.Errorf("ScanBlocks returned %d more blocks, %v; expected 2", n, sc.Err())
+	}
+	//replace the tip alone
+	srv.Reorg(1)
+	srv.Mine()
+	if !sc.Next() || sc.Reorg() == nil || sc.Reorg().ForkHeight != 5 || sc.Block().Height != 6 {
+		t.Errorf("ScanBlocks returned %d with reorg %+v, expected 6 with a fork at 5", sc.Block().Height, sc.Reorg())
+	}
+	//resume a range from a checkpoint
+	sc = bc.ScanBlocks(2, 4, hashes[1], nil)
+	n = 0
+	for ; sc.Next(); n++ {
+		if sc.Reorg() != nil {
+			t.Errorf("ScanBlocks reported a reorg at %d", sc.Block().Height)
+		}
+	}
+	if sc.Err() != nil || n != 3 {
+		t.Errorf("ScanBlocks returned %d blocks, %v; expected 3", n, sc.Err())
+	}
+	sc = bc.ScanBlocks(2, 4, "0000", nil)
+	if !sc.Next() || sc.Reorg() == nil || sc.Reorg().ForkHeight != 0 || sc.Reorg().ForkHash != "" {
+		t.Errorf("ScanBlocks with a stale checkpoint reported %+v", sc.Reorg())
+	}
+}
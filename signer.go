@@ -0,0 +1,225 @@
+package gobcy
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/base58"
+	"github.com/btcsuite/btcd/btcutil/bech32"
+)
+
+//Signer signs hashes with private keys it holds, so a TXSkel
+//can be signed by any key backend with SignWith: in-memory
+//keys (KeySigner), an encrypted keystore (OpenKeystore), or
+//another process (DialSigner).
+type Signer interface {
+	//PubKeys returns the serialized public keys of the
+	//keys held, compressed or not as their addresses are.
+	PubKeys() ([][]byte, error)
+	//SignHash returns the DER-encoded signature of hash
+	//by the key whose public key is pubkey.
+	SignHash(pubkey, hash []byte) (sig []byte, err error)
+}
+
+//KeySigner is a Signer holding private keys in memory.
+type KeySigner struct {
+	keys map[string]*btcec.PrivateKey
+	pubs [][]byte
+}
+
+//NewKeySigner returns an empty KeySigner; add keys to
+//it with Add, AddHex or AddWIF.
+func NewKeySigner() *KeySigner {
+	return &KeySigner{keys: make(map[string]*btcec.PrivateKey)}
+}
+
+//Add adds priv, whose addresses use the compressed
+//or uncompressed public key as compressed says.
+func (ks *KeySigner) Add(priv *btcec.PrivateKey, compressed bool) {
+	pub := priv.PubKey().SerializeUncompressed()
+	if compressed {
+		pub = priv.PubKey().SerializeCompressed()
+	}
+	if _, ok := ks.keys[string(pub)]; !ok {
+		ks.keys[string(pub)] = priv
+		ks.pubs = append(ks.pubs, pub)
+	}
+}
+
+//AddHex adds a hex-encoded private key, like the Private
+//of an AddrKeychain. Since hex doesn't say which public key
+//its addresses use, it's added both compressed and not.
+func (ks *KeySigner) AddHex(priv string) (err error) {
+	privDat, err := hex.DecodeString(priv)
+	if err != nil || len(privDat) != btcec.PrivKeyBytesLen {
+		err = errors.New("*KeySigner.AddHex error: private key isn't " + strconv.Itoa(btcec.PrivKeyBytesLen) + " hex-encoded bytes")
+		return
+	}
+	key, _ := btcec.PrivKeyFromBytes(privDat)
+	ks.Add(key, true)
+	ks.Add(key, false)
+	return
+}
+
+//AddWIF adds a WIF-encoded private key, like the
+//Wif of an AddrKeychain.
+func (ks *KeySigner) AddWIF(wif string) (err error) {
+	w, err := btcutil.DecodeWIF(wif)
+	if err != nil {
+		return
+	}
+	ks.Add(w.PrivKey, w.CompressPubKey)
+	return
+}
+
+//PubKeys returns the public keys of the keys held.
+func (ks *KeySigner) PubKeys() ([][]byte, error) {
+	return ks.pubs, nil
+}
+
+//SignHash signs hash with the key of pubkey.
+func (ks *KeySigner) SignHash(pubkey, hash []byte) (sig []byte, err error) {
+	priv, ok := ks.keys[string(pubkey)]
+	if !ok {
+		err = errors.New("*KeySigner.SignHash error: no key for pubkey " + hex.EncodeToString(pubkey))
+		return
+	}
+	sig = ecdsa.Sign(priv, hash).Serialize()
+	return
+}
+
+//SignWith signs the ToSign data in a TXSkel with the keys of
+//signer, generating the proper Signatures and PubKeys arrays,
+//both hex-encoded. Keys are matched to each input by its
+//addresses (P2PKH, P2WPKH or P2SH-P2WPKH), or by its pubkeys
+//for multisig inputs, which are signed with the first of
+//their keys the signer holds, so no key need be repeated.
+//SignWith fails, naming them, if any inputs have no key.
+//Like Sign, it doesn't check what it signs; call Verify first.
+func (skel *TXSkel) SignWith(signer Signer) (err error) {
+	return skel.signWith(signer, "*TXSkel.SignWith")
+}
+
+//signWith implements Sign and SignWith, whose
+//name fn prefixes its errors.
+func (skel *TXSkel) signWith(signer Signer, fn string) (err error) {
+	pubs, err := signer.PubKeys()
+	if err != nil {
+		return
+	}
+	var sigs, sigPubs [][]byte
+	var missing []string
+	for i, in := range skel.Trans.Inputs {
+		var keys [][]byte
+		need := sigsNeeded(in)
+		if strings.HasPrefix(in.ScriptType, "multisig-") {
+			keys = multisigKeys(in.Addresses, pubs, need)
+		} else if key := addrKey(in.Addresses, pubs); key != nil {
+			keys = [][]byte{key}
+		}
+		if len(keys) < need {
+			missing = append(missing, strconv.Itoa(i)+" ("+strings.Join(in.Addresses, ",")+")")
+		}
+		for len(keys) < need {
+			keys = append(keys, nil)
+		}
+		sigPubs = append(sigPubs, keys...)
+	}
+	if len(missing) > 0 {
+		err = errors.New(fn + " error: no key for inputs " + strings.Join(missing, ", "))
+		return
+	}
+	if len(sigPubs) != len(skel.ToSign) {
+		err = errors.New(fn + " error: inputs don't match the length of ToSign array")
+		return
+	}
+	for i, pub := range sigPubs {
+		tosign, err := hex.DecodeString(skel.ToSign[i])
+		if err != nil {
+			return err
+		}
+		sig, err := signer.SignHash(pub, tosign)
+		if err != nil {
+			return err
+		}
+		sigs = append(sigs, sig)
+	}
+	for i := range sigs {
+		skel.Signatures = append(skel.Signatures, hex.EncodeToString(sigs[i]))
+		skel.PubKeys = append(skel.PubKeys, hex.EncodeToString(sigPubs[i]))
+	}
+	return
+}
+
+//sigsNeeded returns how many signatures in needs, and so
+//how many ToSign entries it has: n for "multisig-n-of-m"
+//inputs, otherwise 1.
+func sigsNeeded(in TXInput) (n int) {
+	n = 1
+	if strings.HasPrefix(in.ScriptType, "multisig-") {
+		parts := strings.Split(in.ScriptType, "-")
+		if len(parts) == 4 {
+			n, _ = strconv.Atoi(parts[1])
+		}
+	}
+	return
+}
+
+//multisigKeys returns up to need of the hex-encoded pubkeys
+//of a multisig input that are in pubs, in the input's order.
+func multisigKeys(pubkeys []string, pubs [][]byte, need int) (keys [][]byte) {
+	for _, k := range pubkeys {
+		pub, err := hex.DecodeString(k)
+		if err != nil {
+			continue
+		}
+		for _, p := range pubs {
+			if len(keys) < need && bytes.Equal(p, pub) {
+				keys = append(keys, p)
+			}
+		}
+	}
+	return
+}
+
+//addrKey returns the key of pubs whose P2PKH, P2WPKH or
+//P2SH-P2WPKH address is one of addrs, or nil if none is.
+func addrKey(addrs []string, pubs [][]byte) []byte {
+	for _, a := range addrs {
+		hash := addrHash(a)
+		if hash == nil {
+			continue
+		}
+		for _, pub := range pubs {
+			keyHash := btcutil.Hash160(pub)
+			program := append([]byte{0x00, 0x14}, keyHash...)
+			if bytes.Equal(hash, keyHash) || bytes.Equal(hash, btcutil.Hash160(program)) {
+				return pub
+			}
+		}
+	}
+	return nil
+}
+
+//addrHash returns the 20-byte hash encoded in a base58 or
+//bech32 address of any coin, or nil if addr isn't one.
+func addrHash(addr string) []byte {
+	if dat, _, err := base58.CheckDecode(addr); err == nil && len(dat) == 20 {
+		return dat
+	}
+	_, dat, err := bech32.Decode(addr)
+	if err != nil || len(dat) < 1 || dat[0] != 0 {
+		return nil
+	}
+	program, err := bech32.ConvertBits(dat[1:], 5, 8, false)
+	if err != nil || len(program) != 20 {
+		return nil
+	}
+	return program
+}
@@ -0,0 +1,136 @@
+package gobcy_test
+
+import (
+	"math/big"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+func TestSignWith(t *testing.T) {
+	srv := gobcytest.NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	fe, err := bc.GetFeeEstimator()
+	if err != nil {
+		t.Fatal("GetFeeEstimator error encountered: ", err)
+	}
+	params, _ := gobcy.ChainParams("bcy", "test")
+	keys1 := fundedKeys(t, srv, bc, 1e5)
+	keys2 := fundedKeys(t, srv, bc, 1e5)
+	dest := fundedKeys(t, srv, bc, 1e5)
+	//one uncompressed key, whose address uses its uncompressed pubkey
+	priv, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncompressed, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(priv.PubKey().SerializeUncompressed()), params)
+	srv.Fund(uncompressed.String(), 1e5)
+	srv.Mine()
+	req := gobcy.TempNewTX(keys1.Address, dest.Address, *big.NewInt(25e4))
+	req.Inputs[0].Addresses = []string{keys1.Address, keys2.Address, uncompressed.String()}
+	req.ChangeAddress = keys1.Address
+	skel, err := bc.NewTX(req, true)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if len(skel.ToSign) != 3 {
+		t.Fatalf("NewTX returned %d tosign, expected 3", len(skel.ToSign))
+	}
+	if err = skel.Verify(req, 2*fe.High, params); err != nil {
+		t.Fatal("Verify error encountered: ", err)
+	}
+	signer := gobcy.NewKeySigner()
+	if err = signer.AddWIF(keys1.Wif); err != nil {
+		t.Fatal("AddWIF error encountered: ", err)
+	}
+	partial := skel
+	if err = partial.SignWith(signer); err == nil || !strings.Contains(err.Error(), keys2.Address) {
+		t.Errorf("Expected error naming the input of %s without a key, got: %v", keys2.Address, err)
+	}
+	if len(partial.Signatures) != 0 {
+		t.Error("SignWith added signatures despite failing")
+	}
+	if err = signer.AddHex(keys2.Private); err != nil {
+		t.Fatal("AddHex error encountered: ", err)
+	}
+	signer.Add(priv, false)
+	//the keystore keeps the keys, and whether they're compressed
+	wif, _ := btcutil.NewWIF(priv, params, false)
+	data, err := gobcy.SealKeystore([]string{keys1.Wif, keys2.Wif, wif.String()}, "hunter2")
+	if err != nil {
+		t.Fatal("SealKeystore error encountered: ", err)
+	}
+	if _, err = gobcy.OpenKeystore(data, "hunter3"); err == nil {
+		t.Error("Expected error opening a keystore with the wrong passphrase")
+	}
+	stored, err := gobcy.OpenKeystore(data, "hunter2")
+	if err != nil {
+		t.Fatal("OpenKeystore error encountered: ", err)
+	}
+	for _, s := range []gobcy.Signer{signer, stored} {
+		signed := skel
+		if err = signed.SignWith(s); err != nil {
+			t.Fatal("SignWith error encountered: ", err)
+		}
+		if len(signed.Signatures) != 3 || len(signed.PubKeys) != 3 {
+			t.Fatalf("SignWith returned %d signatures and %d pubkeys", len(signed.Signatures), len(signed.PubKeys))
+		}
+	}
+	//the same keys, through another "process"
+	l, err := net.Listen("unix", filepath.Join(t.TempDir(), "signer.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go gobcy.ServeSigner(l, stored)
+	remote, err := gobcy.DialSigner("unix", l.Addr().String())
+	if err != nil {
+		t.Fatal("DialSigner error encountered: ", err)
+	}
+	defer remote.Close()
+	if err = skel.SignWith(remote); err != nil {
+		t.Fatal("SignWith error encountered: ", err)
+	}
+	if _, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+	if _, err = remote.SignHash([]byte{2}, make([]byte, 32)); err == nil {
+		t.Error("Expected error from a RemoteSigner without the key")
+	}
+	//multisig inputs are signed with the keys held, in order
+	var ms []gobcy.AddrKeychain
+	var pubkeys []string
+	for i := 0; i < 3; i++ {
+		k, err := bc.GenAddrKeychain()
+		if err != nil {
+			t.Fatal("GenAddrKeychain error encountered: ", err)
+		}
+		ms = append(ms, k)
+		pubkeys = append(pubkeys, k.Public)
+	}
+	srv.Fund(multisigAddr(t, pubkeys, 2, params), 1e5)
+	srv.Mine()
+	spend, _ := gobcy.TempMultiTX("", dest.Address, *big.NewInt(5e4), 2, pubkeys)
+	if skel, err = bc.NewTX(spend, false); err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	msSigner := gobcy.NewKeySigner()
+	msSigner.AddHex(ms[2].Private)
+	if err = skel.SignWith(msSigner); err == nil {
+		t.Error("Expected error signing a 2-of-3 input with one key")
+	}
+	msSigner.AddHex(ms[0].Private)
+	if err = skel.SignWith(msSigner); err != nil {
+		t.Fatal("SignWith error encountered: ", err)
+	}
+	if _, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+}
@@ -0,0 +1,88 @@
+package gobcy
+
+import (
+	"net/url"
+	"strings"
+)
+
+//redacted replaces the API token wherever the
+//library would otherwise print or return it.
+const redacted = "REDACTED"
+
+//WithTokenHeader sends the API token in the named HTTP header
+//instead of the "token" URL parameter, keeping it out of request
+//URLs and the logs of any proxies in between. Only use it against
+//endpoints that accept the token by header, like a gateway in
+//front of BlockCypher; CheckUsage always sends the token in its
+//path.
+func WithTokenHeader(name string) Option {
+	return func(c *config) {
+		c.tokenHdr = name
+	}
+}
+
+//tokenHeader returns the configured token header
+//name, or "" if the token is sent by URL.
+func (api *API) tokenHeader() string {
+	if api.conf == nil {
+		return ""
+	}
+	return api.conf.tokenHdr
+}
+
+//String returns a description of the API
+//that doesn't include its token.
+func (api API) String() string {
+	token := ""
+	if api.Token != "" {
+		token = redacted
+	}
+	return "gobcy.API{Token: " + token + ", Coin: " + api.Coin + ", Chain: " + api.Chain + "}"
+}
+
+//redact replaces the API token within s,
+//including its URL-escaped forms.
+func (api *API) redact(s string) string {
+	if api.Token == "" {
+		return s
+	}
+	for _, t := range []string{api.Token, url.QueryEscape(api.Token), url.PathEscape(api.Token)} {
+		s = strings.Replace(s, t, redacted, -1)
+	}
+	return s
+}
+
+//redactErr returns err with the API token removed
+//from its message. *url.Errors and *APIErrors keep
+//their type, so they still work with errors.As.
+func (api *API) redactErr(err error) error {
+	if err == nil || api.Token == "" {
+		return err
+	}
+	switch e := err.(type) {
+	case *APIError:
+		e.Path = api.redact(e.Path)
+		e.Body = []byte(api.redact(string(e.Body)))
+		for i, m := range e.Messages {
+			e.Messages[i] = api.redact(m)
+		}
+		return e
+	case *url.Error:
+		return &url.Error{Op: e.Op, URL: api.redact(e.URL), Err: api.redactErr(e.Err)}
+	}
+	if msg := err.Error(); api.redact(msg) != msg {
+		return &redactedError{msg: api.redact(msg), err: err}
+	}
+	return err
+}
+
+//redactedError hides the token from the message of an
+//error, while still unwrapping to it for errors.Is/As.
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+
+func (e *redactedError) Unwrap() error { return e.err }
@@ -1,33 +1,114 @@
 package gobcy
 
 import (
-	"encoding/hex"
+	"context"
 	"errors"
 	"math/big"
+	"net/url"
 	"strconv"
-
-	"github.com/btcsuite/btcd/btcec/v2"
-	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
 )
 
 //GetUnTX returns an array of the latest unconfirmed TXs.
 func (api *API) GetUnTX() (txs []TX, err error) {
+	return api.GetUnTXCtx(context.Background())
+}
+
+//GetUnTXCtx is like GetUnTX, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetUnTXCtx(ctx context.Context) (txs []TX, err error) {
 	u, err := api.buildURL("/txs", nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &txs)
+	err = api.getResponse(ctx, u, &txs)
 	return
 }
 
 //GetTX returns a TX represented by the passed hash. Takes
 //an optionally-nil URL parameter map.
+//See TXQuery for its supported params.
 func (api *API) GetTX(hash string, params map[string]string) (tx TX, err error) {
+	return api.GetTXCtx(context.Background(), hash, params)
+}
+
+//GetTXCtx is like GetTX, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetTXCtx(ctx context.Context, hash string, params map[string]string) (tx TX, err error) {
 	u, err := api.buildURL("/txs/"+hash, params)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &tx)
+	err = api.getResponse(ctx, u, &tx)
+	return
+}
+
+//GetTXAll is like GetTX, but follows the TX's NextInputs and
+//NextOutputs URLs, returning a TX with all of its inputs and
+//outputs for transactions too large for one response. The
+//params (see TXQuery) apply to every request; their Limit sets
+//how many inputs and outputs each one returns, and InStart
+//and OutStart skip the inputs and outputs before them.
+func (api *API) GetTXAll(hash string, params map[string]string) (tx TX, err error) {
+	return api.GetTXAllCtx(context.Background(), hash, params)
+}
+
+//GetTXAllCtx is like GetTXAll, but uses ctx
+//for the underlying HTTP requests.
+func (api *API) GetTXAllCtx(ctx context.Context, hash string, params map[string]string) (tx TX, err error) {
+	if tx, err = api.GetTXCtx(ctx, hash, params); err != nil {
+		return
+	}
+	for tx.NextInputs != "" {
+		var page TX
+		if page, err = api.getTXPage(ctx, hash, params, "instart", len(tx.Inputs), tx.NextInputs); err != nil {
+			return
+		}
+		if len(page.Inputs) == 0 {
+			err = errors.New("Func GetTXAll: NextInputs returned no inputs")
+			return
+		}
+		tx.Inputs = append(tx.Inputs, page.Inputs...)
+		tx.NextInputs = page.NextInputs
+	}
+	for tx.NextOutputs != "" {
+		var page TX
+		if page, err = api.getTXPage(ctx, hash, params, "outstart", len(tx.Outputs), tx.NextOutputs); err != nil {
+			return
+		}
+		if len(page.Outputs) == 0 {
+			err = errors.New("Func GetTXAll: NextOutputs returned no outputs")
+			return
+		}
+		tx.Outputs = append(tx.Outputs, page.Outputs...)
+		tx.NextOutputs = page.NextOutputs
+	}
+	return
+}
+
+//getTXPage gets the next page of a TX's inputs or outputs, of
+//which it has have, keeping params and taking the page size
+//and start from next, the NextInputs or NextOutputs URL.
+func (api *API) getTXPage(ctx context.Context, hash string, params map[string]string, startParam string, have int, next string) (tx TX, err error) {
+	nextURL, err := url.Parse(next)
+	if err != nil {
+		err = api.redactErr(err)
+		return
+	}
+	pageParams := make(map[string]string, len(params)+2)
+	for k, v := range params {
+		pageParams[k] = v
+	}
+	if limit := nextURL.Query().Get("limit"); limit != "" {
+		pageParams["limit"] = limit
+	}
+	if start := nextURL.Query().Get(startParam); start != "" {
+		pageParams[startParam] = start
+	} else {
+		//the page after those we have, from the first one asked for
+		first, _ := strconv.Atoi(params[startParam])
+		pageParams[startParam] = strconv.Itoa(first + have)
+	}
+	tx, err = api.GetTXCtx(ctx, hash, pageParams)
 	return
 }
 
@@ -36,11 +117,17 @@ func (api *API) GetTX(hash string, params map[string]string) (tx TX, err error)
 //won't be successfully double-spent against. If the confidence is 1,
 //the transaction has already been confirmed.
 func (api *API) GetTXConf(hash string) (conf TXConf, err error) {
+	return api.GetTXConfCtx(context.Background(), hash)
+}
+
+//GetTXConfCtx is like GetTXConf, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetTXConfCtx(ctx context.Context, hash string) (conf TXConf, err error) {
 	u, err := api.buildURL("/txs/"+hash+"/confidence", nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &conf)
+	err = api.getResponse(ctx, u, &conf)
 	return
 }
 
@@ -98,46 +185,57 @@ func TempMultiTX(inAddr string, outAddr string, amount big.Int, n int, pubkeys [
 //transaction as described in the BlockCypher docs:
 //http://dev.blockcypher.com/#customizing-transaction-requests
 //If verify is true, will include "ToSignTX," which can be used
-//to locally verify the "ToSign" data is valid.
+//to locally verify the "ToSign" data is valid with Verify.
+//If BlockCypher returns the TXSkel with a non-empty Errors array,
+//the TXSkel is still returned alongside an *APIError listing them.
 func (api *API) NewTX(trans TX, verify bool) (skel TXSkel, err error) {
+	return api.NewTXCtx(context.Background(), trans, verify)
+}
+
+//NewTXCtx is like NewTX, but uses ctx
+//for the underlying HTTP request.
+func (api *API) NewTXCtx(ctx context.Context, trans TX, verify bool) (skel TXSkel, err error) {
 	u, err := api.buildURL("/txs/new",
 		map[string]string{"includeToSignTx": strconv.FormatBool(verify)})
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &trans, &skel)
+	err = api.postRetryResponse(ctx, retryPOST, u, &trans, &skel)
 	return
 }
 
-//Sign takes a hex-encoded string slice of private
-//keys and uses them to sign the ToSign data in a
-//TXSkel, generating the proper Signatures and PubKeys
-//array, both hex-encoded. This is meant as a helper
-//function, and leverages btcd's btcec library.
+//Sign takes a string slice of private keys, each either
+//WIF-encoded or hex-encoded, and uses them to sign the
+//ToSign data in a TXSkel, generating the proper Signatures
+//and PubKeys array, both hex-encoded. Each ToSign entry is
+//matched to its key by the addresses of its input in Trans,
+//so keys needn't be repeated or ordered; hex keys sign for
+//both the compressed and uncompressed addresses of their
+//key, and WIF keys for the one they're encoded for. If any
+//input has no matching key, Sign returns an error naming it.
+//See SignWith to use keys not held in memory. This is meant
+//as a helper function, and leverages btcd's btcec library.
+//Sign doesn't check what it signs; call Verify first.
 func (skel *TXSkel) Sign(priv []string) (err error) {
-	//num of private keys must match len(ToSign)
-	//Often this might mean repeating private keys
-	if len(priv) != len(skel.ToSign) {
-		err = errors.New("*TXSkel.Sign error: number of private keys != length of ToSign array")
-		return
-	}
-	//Loop through keys, append sigs/public key
+	signer := NewKeySigner()
 	for i, k := range priv {
-		privDat, err := hex.DecodeString(k)
-		if err != nil {
-			return err
+		if err = signer.AddWIF(k); err == nil {
+			continue
 		}
-		tosign, err := hex.DecodeString(skel.ToSign[i])
-		if err != nil {
-			return err
+		if signer.AddHex(k) != nil {
+			return errors.New("*TXSkel.Sign error: private key " + strconv.Itoa(i) + " is neither WIF nor hex-encoded")
 		}
-		privkey, pubkey := btcec.PrivKeyFromBytes(privDat)
-		sig := ecdsa.Sign(privkey, tosign)
-		if sig == nil {
-			return errors.New("error during signature")
+	}
+	return skel.signWith(signer, "*TXSkel.Sign")
+}
+
+//apiErrors lists the errors BlockCypher attached
+//to this TXSkel, so they surface as an *APIError.
+func (skel *TXSkel) apiErrors() (msgs []string) {
+	for _, e := range skel.Errors {
+		if e.Error != "" {
+			msgs = append(msgs, e.Error)
 		}
-		skel.Signatures = append(skel.Signatures, hex.EncodeToString(sig.Serialize()))
-		skel.PubKeys = append(skel.PubKeys, hex.EncodeToString(pubkey.SerializeCompressed()))
 	}
 	return
 }
@@ -147,23 +245,37 @@ func (skel *TXSkel) Sign(priv []string) (err error) {
 //network. TXSkel requires a fully formed TX, Signatures,
 //and PubKeys. PubKeys should not be included in the
 //special case of multi-sig addresses.
+//SendTX is only retried if RetryPolicy.RetryBroadcast is set.
 func (api *API) SendTX(skel TXSkel) (trans TXSkel, err error) {
+	return api.SendTXCtx(context.Background(), skel)
+}
+
+//SendTXCtx is like SendTX, but uses ctx
+//for the underlying HTTP request.
+func (api *API) SendTXCtx(ctx context.Context, skel TXSkel) (trans TXSkel, err error) {
 	u, err := api.buildURL("/txs/send", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &skel, &trans)
+	err = api.postRetryResponse(ctx, retryBroadcast, u, &skel, &trans)
 	return
 }
 
 //PushTX takes a hex-encoded transaction string
 //and pushes it directly to the Coin/Chain network.
+//PushTX is only retried if RetryPolicy.RetryBroadcast is set.
 func (api *API) PushTX(hex string) (trans TXSkel, err error) {
+	return api.PushTXCtx(context.Background(), hex)
+}
+
+//PushTXCtx is like PushTX, but uses ctx
+//for the underlying HTTP request.
+func (api *API) PushTXCtx(ctx context.Context, hex string) (trans TXSkel, err error) {
 	u, err := api.buildURL("/txs/push", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &map[string]string{"tx": hex}, &trans)
+	err = api.postRetryResponse(ctx, retryBroadcast, u, &map[string]string{"tx": hex}, &trans)
 	return
 }
 
@@ -171,10 +283,16 @@ func (api *API) PushTX(hex string) (trans TXSkel, err error) {
 //and decodes it into a TX object, without sending
 //it along to the Coin/Chain network.
 func (api *API) DecodeTX(hex string) (trans TX, err error) {
+	return api.DecodeTXCtx(context.Background(), hex)
+}
+
+//DecodeTXCtx is like DecodeTX, but uses ctx
+//for the underlying HTTP request.
+func (api *API) DecodeTXCtx(ctx context.Context, hex string) (trans TX, err error) {
 	u, err := api.buildURL("/txs/decode", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &map[string]string{"tx": hex}, &trans)
+	err = api.postRetryResponse(ctx, retryPOST, u, &map[string]string{"tx": hex}, &trans)
 	return
 }
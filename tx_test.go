@@ -0,0 +1,126 @@
+package gobcy_test
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+)
+
+func TestGetTXAll(t *testing.T) {
+	srv := gobcytest.NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	keys, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	var privs []string
+	for i := 0; i < 25; i++ {
+		srv.Fund(keys.Address, 1e5)
+		privs = append(privs, keys.Private)
+	}
+	srv.Mine()
+	//sweep all 25 outputs into 30
+	temp := gobcy.TX{Inputs: []gobcy.TXInput{{Addresses: []string{keys.Address}}}}
+	for i := 0; i < 30; i++ {
+		out := gobcy.TXOutput{Addresses: []string{keys.Address}}
+		out.Value.SetInt64(1000)
+		if i == 0 {
+			out.Value.SetInt64(-1)
+		}
+		temp.Outputs = append(temp.Outputs, out)
+	}
+	skel, err := bc.NewTX(temp, false)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = skel.Sign(privs); err != nil {
+		t.Fatal("*TXSkel.Sign error encountered: ", err)
+	}
+	if skel, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+	tx, err := bc.GetTX(skel.Trans.Hash, gobcy.TXQuery{Limit: 10}.Params())
+	if err != nil {
+		t.Fatal("GetTX error encountered: ", err)
+	}
+	if len(tx.Inputs) != 10 || tx.NextInputs == "" || tx.NextOutputs == "" {
+		t.Fatalf("GetTX returned %d inputs, expected a page of 10", len(tx.Inputs))
+	}
+	tx, err = bc.GetTXAll(skel.Trans.Hash, gobcy.TXQuery{Limit: 10}.Params())
+	if err != nil {
+		t.Fatal("GetTXAll error encountered: ", err)
+	}
+	if len(tx.Inputs) != 25 || len(tx.Outputs) != 30 || tx.NextInputs != "" || tx.NextOutputs != "" {
+		t.Fatalf("GetTXAll returned %d inputs and %d outputs, expected 25 and 30", len(tx.Inputs), len(tx.Outputs))
+	}
+	seen := make(map[string]bool)
+	for _, in := range tx.Inputs {
+		seen[in.PrevHash] = true
+	}
+	if len(seen) != 25 {
+		t.Errorf("GetTXAll returned %d distinct inputs, expected 25", len(seen))
+	}
+	//paging from InStart and OutStart
+	from, err := bc.GetTXAll(skel.Trans.Hash, gobcy.TXQuery{Limit: 10, InStart: 3, OutStart: 7}.Params())
+	if err != nil {
+		t.Fatal("GetTXAll error encountered: ", err)
+	}
+	if len(from.Inputs) != 22 || len(from.Outputs) != 23 {
+		t.Fatalf("GetTXAll returned %d inputs and %d outputs, expected 22 and 23", len(from.Inputs), len(from.Outputs))
+	}
+	for i, in := range from.Inputs {
+		if in.PrevHash != tx.Inputs[i+3].PrevHash || in.OutputIndex != tx.Inputs[i+3].OutputIndex {
+			t.Fatalf("GetTXAll returned input %d out of order", i)
+		}
+	}
+}
+
+func TestSignKeys(t *testing.T) {
+	srv := gobcytest.NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	params, _ := gobcy.ChainParams("bcy", "test")
+	keys1 := fundedKeys(t, srv, bc, 1e5)
+	keys2 := fundedKeys(t, srv, bc, 1e5)
+	priv, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	uncompressed, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(priv.PubKey().SerializeUncompressed()), params)
+	for i := 0; i < 2; i++ {
+		srv.Fund(uncompressed.String(), 1e5)
+	}
+	srv.Mine()
+	req := gobcy.TempNewTX(keys1.Address, keys1.Address, *big.NewInt(35e4))
+	req.Inputs[0].Addresses = []string{uncompressed.String(), keys1.Address, keys2.Address}
+	skel, err := bc.NewTX(req, false)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if len(skel.ToSign) != 4 {
+		t.Fatalf("NewTX returned %d tosign, expected 4", len(skel.ToSign))
+	}
+	privHex := hex.EncodeToString(priv.Serialize())
+	bad := skel
+	err = bad.Sign([]string{privHex, keys1.Wif})
+	if err == nil || !strings.Contains(err.Error(), keys2.Address) || strings.Contains(err.Error(), keys1.Address) {
+		t.Errorf("Expected error naming only the input of %s, got: %v", keys2.Address, err)
+	}
+	if err = bad.Sign([]string{"not a key"}); err == nil {
+		t.Error("Expected error signing with an invalid key")
+	}
+	//keys in any order, each once, hex for the uncompressed one
+	if err = skel.Sign([]string{keys2.Wif, privHex, keys1.Private}); err != nil {
+		t.Fatal("*TXSkel.Sign error encountered: ", err)
+	}
+	if _, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+}
@@ -0,0 +1,417 @@
+package gobcy
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+//Verify checks a TXSkel returned by NewTX with verify set to
+//true against req, the TX passed to NewTX, so a compromised or
+//buggy server can't trick you into signing a different payment.
+//Call it before Sign, and don't sign if it returns an error.
+//params are those of the Coin/Chain, see ChainParams.
+//
+//Verify checks that every ToSign is the hash of its ToSignTX,
+//that each ToSignTX signs its own input of Trans, and the
+//version, lock time, sequences, inputs and outputs of Trans,
+//with SIGHASH_ALL, that the outputs pay exactly what req asked
+//for, that any other output is change paying back to req's
+//input addresses or ChangeAddress, and that the fees add up to
+//Trans.Fees (and req.Fees, if set). Change to the addresses of
+//a Wallet used by name can't be checked; set ChangeAddress.
+//
+//Since the server picks the inputs, it could spend more of
+//yours than needed and pay the rest as fees, so those are
+//bounded too: by req.Fees if set, and by maxFeeRate, in
+//satoshis per virtual byte (see FeeEstimator), if positive.
+//Verify fails if neither is set. The values of legacy
+//(non-segwit) inputs aren't part of what they sign, so the
+//fees of those are only as reliable as the input values
+//BlockCypher reports.
+func (skel *TXSkel) Verify(req TX, maxFeeRate float64, params *chaincfg.Params) (err error) {
+	if len(skel.ToSignTX) != len(skel.ToSign) {
+		return errors.New("*TXSkel.Verify error: length of ToSignTX != length of ToSign array, use NewTX with verify set to true")
+	}
+	if req.Fees.Sign() <= 0 && maxFeeRate <= 0 {
+		return errors.New("*TXSkel.Verify error: set req.Fees or maxFeeRate to bound the fees")
+	}
+	outs, err := skel.outputs()
+	if err != nil {
+		return
+	}
+	if err = checkOutputs(req, outs, params); err != nil {
+		return
+	}
+	var prevouts, seqs, outBuf bytes.Buffer
+	var prevs []prevScript
+	for i, in := range skel.Trans.Inputs {
+		hash, err := chainhash.NewHashFromStr(in.PrevHash)
+		if err != nil {
+			return err
+		}
+		prevouts.Write(hash[:])
+		binary.Write(&prevouts, binary.LittleEndian, uint32(in.OutputIndex))
+		binary.Write(&seqs, binary.LittleEndian, uint32(in.Sequence))
+		prev, err := prevScriptOf(in, params)
+		if err != nil {
+			return errors.New("*TXSkel.Verify error: input " + strconv.Itoa(i) + ": " + err.Error())
+		}
+		prevs = append(prevs, prev)
+	}
+	for _, out := range outs {
+		wire.WriteTxOut(&outBuf, 0, 0, out)
+	}
+	hashes := sigHashes{
+		prevouts: chainhash.DoubleHashB(prevouts.Bytes()),
+		sequence: chainhash.DoubleHashB(seqs.Bytes()),
+		outputs:  chainhash.DoubleHashB(outBuf.Bytes()),
+	}
+	//each input has as many ToSign as signatures it needs
+	k := 0
+	for i, in := range skel.Trans.Inputs {
+		for c := 0; c < sigsNeeded(in); c++ {
+			if k == len(skel.ToSign) {
+				return errors.New("*TXSkel.Verify error: inputs don't match the length of ToSign array")
+			}
+			pre, err := hex.DecodeString(skel.ToSignTX[k])
+			if err != nil {
+				return err
+			}
+			if hex.EncodeToString(chainhash.DoubleHashB(pre)) != skel.ToSign[k] {
+				return errors.New("*TXSkel.Verify error: ToSign " + strconv.Itoa(k) + " is not the hash of its ToSignTX")
+			}
+			if err = skel.checkPreimage(pre, i, prevs[i], hashes, outs); err != nil {
+				return errors.New("*TXSkel.Verify error: ToSignTX " + strconv.Itoa(k) + ": " + err.Error())
+			}
+			k++
+		}
+	}
+	if k != len(skel.ToSign) {
+		return errors.New("*TXSkel.Verify error: inputs don't match the length of ToSign array")
+	}
+	var fees int64
+	for _, in := range skel.Trans.Inputs {
+		fees += int64(in.OutputValue)
+	}
+	for _, out := range outs {
+		fees -= out.Value
+	}
+	if fees != skel.Trans.Fees.Int64() || (req.Fees.Sign() > 0 && fees != req.Fees.Int64()) {
+		return errors.New("*TXSkel.Verify error: fees of " + strconv.FormatInt(fees, 10) + " don't match the requested fees")
+	}
+	if maxFeeRate > 0 {
+		var inputs []string
+		var sizes []int
+		for _, prev := range prevs {
+			inputs = append(inputs, prev.scriptType)
+		}
+		for _, out := range outs {
+			sizes = append(sizes, out.SerializeSize())
+		}
+		weight, err := txWeight(inputs, sizes)
+		if err != nil {
+			return errors.New("*TXSkel.Verify error: " + err.Error())
+		}
+		if max := FeeFor(maxFeeRate, (weight+3)/4); fees > max.Int64() {
+			return errors.New("*TXSkel.Verify error: fees of " + strconv.FormatInt(fees, 10) + " exceed the maximum of " + max.String())
+		}
+	}
+	return
+}
+
+//outputs returns the outputs of Trans as wire outputs.
+func (skel *TXSkel) outputs() (outs []*wire.TxOut, err error) {
+	for i, out := range skel.Trans.Outputs {
+		if out.Script == "" {
+			err = errors.New("*TXSkel.Verify error: output " + strconv.Itoa(i) + " has no script")
+			return
+		}
+		script, err := hex.DecodeString(out.Script)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, wire.NewTxOut(out.Value.Int64(), script))
+	}
+	return
+}
+
+//prevScript is the output an input of Trans spends, as its
+//addresses show it: its script, the redeem script if it's
+//multisig, and its script type as EstimateVSize takes it.
+type prevScript struct {
+	script     []byte
+	redeem     []byte
+	scriptType string
+}
+
+//prevScriptOf returns the prevScript of in.
+func prevScriptOf(in TXInput, params *chaincfg.Params) (prev prevScript, err error) {
+	if strings.HasPrefix(in.ScriptType, "multisig-") {
+		if prev.redeem, err = multisigRedeem(in.Addresses, in.ScriptType, params); err != nil {
+			return
+		}
+		prev.script, err = p2shScript(prev.redeem)
+		prev.scriptType = in.ScriptType
+		return
+	}
+	if prev.script, err = scriptOf(in.Addresses, "", "", "", params); err != nil {
+		return
+	}
+	switch txscript.GetScriptClass(prev.script) {
+	case txscript.PubKeyHashTy:
+		prev.scriptType = "pay-to-pubkey-hash"
+	case txscript.WitnessV0PubKeyHashTy:
+		prev.scriptType = "pay-to-witness-pubkey-hash"
+	case txscript.ScriptHashTy:
+		//a P2SH input without pubkeys must be P2SH-P2WPKH
+		prev.scriptType = "pay-to-script-hash"
+	default:
+		err = errors.New("unsupported script type")
+	}
+	return
+}
+
+//sigHashes are the BIP-143 hashes of the prevouts,
+//sequences and outputs of Trans.
+type sigHashes struct {
+	prevouts, sequence, outputs []byte
+}
+
+//checkPreimage checks that pre, a legacy or BIP-143 sighash
+//preimage, signs input i of Trans, spending prev, along with
+//the rest of Trans and the outputs outs, with SIGHASH_ALL.
+func (skel *TXSkel) checkPreimage(pre []byte, i int, prev prevScript, hashes sigHashes, outs []*wire.TxOut) error {
+	if len(pre) < 4 || binary.LittleEndian.Uint32(pre[len(pre)-4:]) != uint32(txscript.SigHashAll) {
+		return errors.New("not signed with SIGHASH_ALL")
+	}
+	//a legacy preimage is the whole TX, which a BIP-143
+	//one practically never also parses as
+	tx := wire.NewMsgTx(wire.TxVersion)
+	r := bytes.NewReader(pre[:len(pre)-4])
+	if err := tx.DeserializeNoWitness(r); err == nil && r.Len() == 0 {
+		return skel.checkLegacy(tx, i, prev, outs)
+	}
+	//BIP-143: version, hashPrevouts, hashSequence, outpoint,
+	//scriptCode, amount, sequence, hashOutputs, locktime, hashtype
+	if len(pre) > 104 {
+		r = bytes.NewReader(pre[104:])
+		if code, err := wire.ReadVarBytes(r, 0, 10000, "scriptCode"); err == nil && r.Len() == 52 {
+			in := skel.Trans.Inputs[i]
+			tail := pre[len(pre)-52:]
+			switch {
+			case binary.LittleEndian.Uint32(pre[:4]) != uint32(skel.Trans.Ver):
+				return errors.New("version doesn't match")
+			case !bytes.Equal(pre[4:36], hashes.prevouts):
+				return errors.New("inputs don't match")
+			case !bytes.Equal(pre[36:68], hashes.sequence):
+				return errors.New("sequences don't match")
+			case !matchesOutPoint(pre[68:104], in):
+				return errors.New("doesn't sign input " + strconv.Itoa(i))
+			case !witnessCode(code, prev.script):
+				return errors.New("scriptCode doesn't match input " + strconv.Itoa(i))
+			case int64(binary.LittleEndian.Uint64(tail[:8])) != int64(in.OutputValue):
+				return errors.New("input value doesn't match")
+			case binary.LittleEndian.Uint32(tail[8:12]) != uint32(in.Sequence):
+				return errors.New("sequences don't match")
+			case !bytes.Equal(tail[12:44], hashes.outputs):
+				return errors.New("outputs don't match")
+			case binary.LittleEndian.Uint32(tail[44:48]) != uint32(skel.Trans.LockTime):
+				return errors.New("lock time doesn't match")
+			}
+			return nil
+		}
+	}
+	return errors.New("not a sighash preimage")
+}
+
+//witnessCode reports whether code is the BIP-143 scriptCode
+//of a P2WPKH or P2SH-P2WPKH output with script.
+func witnessCode(code, script []byte) bool {
+	if txscript.GetScriptClass(code) != txscript.PubKeyHashTy {
+		return false
+	}
+	program := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, code[3:23]...)
+	if txscript.IsPayToScriptHash(script) {
+		return bytes.Equal(script[2:22], btcutil.Hash160(program))
+	}
+	return bytes.Equal(script, program)
+}
+
+//checkLegacy checks that tx, from a legacy sighash preimage,
+//signs input i of Trans, spending prev, along with the rest
+//of Trans and the outputs outs.
+func (skel *TXSkel) checkLegacy(tx *wire.MsgTx, i int, prev prevScript, outs []*wire.TxOut) error {
+	if len(tx.TxIn) != len(skel.Trans.Inputs) || len(tx.TxOut) != len(outs) {
+		return errors.New("inputs or outputs don't match")
+	}
+	if tx.Version != int32(skel.Trans.Ver) {
+		return errors.New("version doesn't match")
+	}
+	if tx.LockTime != uint32(skel.Trans.LockTime) {
+		return errors.New("lock time doesn't match")
+	}
+	//the scriptCode is the redeem script of
+	//multisig inputs, and the P2PKH script otherwise
+	code := prev.redeem
+	if code == nil && txscript.IsPayToPubKeyHash(prev.script) {
+		code = prev.script
+	}
+	for j, in := range tx.TxIn {
+		var op bytes.Buffer
+		op.Write(in.PreviousOutPoint.Hash[:])
+		binary.Write(&op, binary.LittleEndian, in.PreviousOutPoint.Index)
+		switch {
+		case !matchesOutPoint(op.Bytes(), skel.Trans.Inputs[j]):
+			return errors.New("inputs don't match")
+		case in.Sequence != uint32(skel.Trans.Inputs[j].Sequence):
+			return errors.New("sequences don't match")
+		case j != i && len(in.SignatureScript) > 0:
+			return errors.New("signs input " + strconv.Itoa(j) + ", not " + strconv.Itoa(i))
+		case j == i && (code == nil || !bytes.Equal(in.SignatureScript, code)):
+			return errors.New("scriptCode doesn't match input " + strconv.Itoa(i))
+		}
+	}
+	for j, out := range tx.TxOut {
+		if out.Value != outs[j].Value || !bytes.Equal(out.PkScript, outs[j].PkScript) {
+			return errors.New("outputs don't match")
+		}
+	}
+	return nil
+}
+
+//matchesOutPoint reports whether op, a serialized
+//outpoint, is the one spent by in.
+func matchesOutPoint(op []byte, in TXInput) bool {
+	hash, err := chainhash.NewHashFromStr(in.PrevHash)
+	return err == nil && bytes.Equal(op[:32], hash[:]) &&
+		binary.LittleEndian.Uint32(op[32:36]) == uint32(in.OutputIndex)
+}
+
+//checkOutputs checks that outs pay what req asked for,
+//and otherwise only change back to req's addresses.
+func checkOutputs(req TX, outs []*wire.TxOut, params *chaincfg.Params) (err error) {
+	used := make([]bool, len(outs))
+	for i, want := range req.Outputs {
+		script, err := scriptOf(want.Addresses, want.ScriptType, want.DataHex, want.DataString, params)
+		if err != nil {
+			return errors.New("*TXSkel.Verify error: requested output " + strconv.Itoa(i) + ": " + err.Error())
+		}
+		value, found := want.Value.Int64(), false
+		for j, out := range outs {
+			if !used[j] && bytes.Equal(out.PkScript, script) && (out.Value == value || (value == -1 && out.Value > 0)) {
+				used[j], found = true, true
+				break
+			}
+		}
+		if !found {
+			return errors.New("*TXSkel.Verify error: requested output " + strconv.Itoa(i) + " is missing or pays a different amount")
+		}
+	}
+	var change [][]byte
+	if req.ChangeAddress != "" {
+		script, err := scriptOf([]string{req.ChangeAddress}, "", "", "", params)
+		if err != nil {
+			return errors.New("*TXSkel.Verify error: ChangeAddress: " + err.Error())
+		}
+		change = append(change, script)
+	}
+	for _, in := range req.Inputs {
+		if strings.HasPrefix(in.ScriptType, "multisig-") {
+			if script, err := scriptOf(in.Addresses, in.ScriptType, "", "", params); err == nil {
+				change = append(change, script)
+			}
+			continue
+		}
+		for _, a := range in.Addresses {
+			//wallet names aren't addresses, and are skipped
+			if script, err := scriptOf([]string{a}, "", "", "", params); err == nil {
+				change = append(change, script)
+			}
+		}
+	}
+	for j, out := range outs {
+		if used[j] {
+			continue
+		}
+		found := false
+		for _, script := range change {
+			found = found || bytes.Equal(out.PkScript, script)
+		}
+		if !found {
+			return errors.New("*TXSkel.Verify error: output " + strconv.Itoa(j) + " is neither requested nor change to our addresses")
+		}
+	}
+	return
+}
+
+//scriptOf returns the output script paying to addrs, which are
+//pubkeys for a "multisig-n-of-m" scriptType, or embedding the
+//data for a "null-data" one.
+func scriptOf(addrs []string, scriptType, dataHex, dataString string, params *chaincfg.Params) (script []byte, err error) {
+	switch {
+	case scriptType == "null-data":
+		data := []byte(dataString)
+		if dataHex != "" {
+			if data, err = hex.DecodeString(dataHex); err != nil {
+				return
+			}
+		}
+		return txscript.NullDataScript(data)
+	case strings.HasPrefix(scriptType, "multisig-"):
+		redeem, err := multisigRedeem(addrs, scriptType, params)
+		if err != nil {
+			return nil, err
+		}
+		addr, err := btcutil.NewAddressScriptHash(redeem, params)
+		if err != nil {
+			return nil, err
+		}
+		return txscript.PayToAddrScript(addr)
+	case len(addrs) != 1:
+		err = errors.New("no single script pays " + strconv.Itoa(len(addrs)) + " addresses")
+		return
+	}
+	addr, err := btcutil.DecodeAddress(addrs[0], params)
+	if err != nil {
+		return
+	}
+	return txscript.PayToAddrScript(addr)
+}
+
+//multisigRedeem returns the redeem script of a "multisig-n-of-m"
+//scriptType with addrs as its hex-encoded pubkeys.
+func multisigRedeem(addrs []string, scriptType string, params *chaincfg.Params) (redeem []byte, err error) {
+	var n, m int
+	parts := strings.Split(scriptType, "-")
+	if len(parts) == 4 {
+		n, _ = strconv.Atoi(parts[1])
+		m, _ = strconv.Atoi(parts[3])
+	}
+	if n == 0 || m != len(addrs) {
+		err = errors.New("invalid script type " + scriptType)
+		return
+	}
+	var keys []*btcutil.AddressPubKey
+	for _, a := range addrs {
+		pub, err := hex.DecodeString(a)
+		if err != nil {
+			return nil, err
+		}
+		key, err := btcutil.NewAddressPubKey(pub, params)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return txscript.MultiSigScript(keys, n)
+}
@@ -0,0 +1,249 @@
+package gobcy_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/blockcypher/gobcy/v2"
+	"github.com/blockcypher/gobcy/v2/gobcytest"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestVerify(t *testing.T) {
+	srv := gobcytest.NewServer("bcy", "test")
+	defer srv.Close()
+	bc := srv.API("token")
+	fe, err := bc.GetFeeEstimator()
+	if err != nil {
+		t.Fatal("GetFeeEstimator error encountered: ", err)
+	}
+	params, _ := gobcy.ChainParams("bcy", "test")
+	keys1 := fundedKeys(t, srv, bc, 1e5)
+	keys2 := fundedKeys(t, srv, bc, 2e5)
+	req := gobcy.TempNewTX(keys2.Address, keys1.Address, *big.NewInt(45000))
+	skel, err := bc.NewTX(req, true)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = skel.Verify(req, 2*fe.High, params); err != nil {
+		t.Fatal("Verify error encountered: ", err)
+	}
+	//a server paying someone else, or less, is caught
+	evil := gobcy.TempNewTX(keys2.Address, keys2.Address, *big.NewInt(45000))
+	evilSkel, err := bc.NewTX(evil, true)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = evilSkel.Verify(req, 2*fe.High, params); err == nil {
+		t.Error("Expected error verifying a TX paying another address")
+	}
+	tampered := skel
+	tampered.Trans.Outputs = append([]gobcy.TXOutput(nil), skel.Trans.Outputs...)
+	tampered.Trans.Outputs[0].Value = *big.NewInt(44000)
+	if err = tampered.Verify(req, 2*fe.High, params); err == nil {
+		t.Error("Expected error verifying a TX paying a different amount")
+	}
+	tampered = skel
+	tampered.ToSign = []string{strings.Repeat("00", 32)}
+	if err = tampered.Verify(req, 2*fe.High, params); err == nil {
+		t.Error("Expected error verifying a ToSign that isn't the hash of its ToSignTX")
+	}
+	//so are preimages signing anything else, rehashed to match
+	tampers := map[string]func(*wire.MsgTx){
+		"lock time": func(tx *wire.MsgTx) { tx.LockTime = 499999999 },
+		"version":   func(tx *wire.MsgTx) { tx.Version = 2 },
+		"sequence":  func(tx *wire.MsgTx) { tx.TxIn[0].Sequence = 0 },
+		"scriptCode": func(tx *wire.MsgTx) {
+			tx.TxIn[0].SignatureScript = tx.TxOut[0].PkScript
+		},
+	}
+	for field, tamper := range tampers {
+		bad := legacyTampered(t, skel, 0, tamper)
+		if err = bad.Verify(req, 2*fe.High, params); err == nil {
+			t.Errorf("Expected error verifying a ToSignTX with a different %s", field)
+		}
+	}
+	if err = skel.Verify(req, 0, params); err == nil {
+		t.Error("Expected error verifying with neither Fees nor a fee rate to bound the fees")
+	}
+	//a server spending more of our coins on fees is caught
+	srv.Fund(keys2.Address, 1e5)
+	srv.Mine()
+	inflated := req
+	inflated.Fees = *big.NewInt(2e5)
+	inflatedSkel, err := bc.NewTX(inflated, true)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if len(inflatedSkel.ToSign) != 2 {
+		t.Fatalf("NewTX returned %d tosign, expected 2", len(inflatedSkel.ToSign))
+	}
+	if err = inflatedSkel.Verify(req, 2*fe.High, params); err == nil {
+		t.Error("Expected error verifying a TX paying more than the maximum fee rate")
+	}
+	if err = inflatedSkel.Verify(inflated, 0, params); err != nil {
+		t.Error("Verify of a TX paying the requested fees error encountered: ", err)
+	}
+	//each ToSignTX must sign its own input
+	pre0, _ := hex.DecodeString(inflatedSkel.ToSignTX[0])
+	pre1, _ := hex.DecodeString(inflatedSkel.ToSignTX[1])
+	swapped := resigned(resigned(inflatedSkel, 0, pre1), 1, pre0)
+	if err = swapped.Verify(inflated, 0, params); err == nil {
+		t.Error("Expected error verifying ToSignTX signing each other's inputs")
+	}
+	//change must come back to our addresses
+	withChange := req
+	withChange.ChangeAddress = keys1.Address
+	changeSkel, err := bc.NewTX(withChange, true)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = changeSkel.Verify(withChange, 2*fe.High, params); err != nil {
+		t.Error("Verify error encountered: ", err)
+	}
+	if err = changeSkel.Verify(req, 2*fe.High, params); err == nil {
+		t.Error("Expected error verifying change to an address we didn't ask for")
+	}
+	if err = skel.Sign([]string{keys2.Private}); err != nil {
+		t.Fatal("*TXSkel.Sign error encountered: ", err)
+	}
+	if _, err = bc.SendTX(skel); err != nil {
+		t.Fatal("SendTX error encountered: ", err)
+	}
+	//multisig inputs and sweeps
+	var pubkeys []string
+	for i := 0; i < 3; i++ {
+		k, err := bc.GenAddrKeychain()
+		if err != nil {
+			t.Fatal("GenAddrKeychain error encountered: ", err)
+		}
+		pubkeys = append(pubkeys, k.Public)
+	}
+	fund, _ := gobcy.TempMultiTX(keys1.Address, "", *big.NewInt(5e4), 2, pubkeys)
+	if skel, err = bc.NewTX(fund, true); err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = skel.Verify(fund, 2*fe.High, params); err != nil {
+		t.Error("Verify of a TX funding a multisig address error encountered: ", err)
+	}
+	srv.Fund(multisigAddr(t, pubkeys, 2, params), 1e5)
+	srv.Mine()
+	spend, _ := gobcy.TempMultiTX("", keys2.Address, *big.NewInt(-1), 2, pubkeys)
+	if skel, err = bc.NewTX(spend, true); err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if len(skel.ToSign) != 2 {
+		t.Fatalf("NewTX returned %d tosign, expected 2", len(skel.ToSign))
+	}
+	if err = skel.Verify(spend, 2*fe.High, params); err != nil {
+		t.Error("Verify of a multisig sweep error encountered: ", err)
+	}
+}
+
+func TestVerifySegwit(t *testing.T) {
+	srv := gobcytest.NewServer("btc", "test3")
+	defer srv.Close()
+	bc := srv.API("token")
+	fe, err := bc.GetFeeEstimator()
+	if err != nil {
+		t.Fatal("GetFeeEstimator error encountered: ", err)
+	}
+	params := &chaincfg.TestNet3Params
+	priv, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from, _ := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(priv.PubKey().SerializeCompressed()), params)
+	srv.Fund(from.String(), 1e5)
+	srv.Mine()
+	dest, err := bc.GenAddrKeychain()
+	if err != nil {
+		t.Fatal("GenAddrKeychain error encountered: ", err)
+	}
+	req := gobcy.TempNewTX(from.String(), dest.Address, *big.NewInt(45000))
+	skel, err := bc.NewTX(req, true)
+	if err != nil {
+		t.Fatal("NewTX error encountered: ", err)
+	}
+	if err = skel.Verify(req, 2*fe.High, params); err != nil {
+		t.Fatal("Verify error encountered: ", err)
+	}
+	//a segwit input's value is signed, so lying about it is caught
+	tampered := skel
+	tampered.Trans.Inputs = append([]gobcy.TXInput(nil), skel.Trans.Inputs...)
+	tampered.Trans.Inputs[0].OutputValue = 2e5
+	tampered.Trans.Fees = *big.NewInt(skel.Trans.Fees.Int64() + 1e5)
+	if err = tampered.Verify(req, 2*fe.High, params); err == nil {
+		t.Error("Expected error verifying a TX with a wrong input value")
+	}
+	//as is any other field of its preimage, rehashed to match
+	pre, _ := hex.DecodeString(skel.ToSignTX[0])
+	offsets := map[string]int{
+		"version":      0,
+		"hashSequence": 36,
+		"outpoint":     68 + 32,
+		"scriptCode":   105,
+		"sequence":     len(pre) - 44,
+		"lock time":    len(pre) - 8,
+		"hashPrevouts": 4,
+		"value":        len(pre) - 52,
+		"hashOutputs":  len(pre) - 40,
+	}
+	for field, at := range offsets {
+		badPre := append([]byte(nil), pre...)
+		badPre[at] ^= 1
+		bad := resigned(skel, 0, badPre)
+		if err = bad.Verify(req, 2*fe.High, params); err == nil {
+			t.Errorf("Expected error verifying a ToSignTX with a different %s", field)
+		}
+	}
+}
+
+//resigned returns skel with its ToSignTX k replaced by pre,
+//and its ToSign k by the hash of pre, as a server could.
+func resigned(skel gobcy.TXSkel, k int, pre []byte) gobcy.TXSkel {
+	skel.ToSign = append([]string(nil), skel.ToSign...)
+	skel.ToSignTX = append([]string(nil), skel.ToSignTX...)
+	skel.ToSign[k] = hex.EncodeToString(chainhash.DoubleHashB(pre))
+	skel.ToSignTX[k] = hex.EncodeToString(pre)
+	return skel
+}
+
+//legacyTampered returns skel with the TX of its legacy
+//ToSignTX k changed by tamper, and its ToSign k to match.
+func legacyTampered(t *testing.T, skel gobcy.TXSkel, k int, tamper func(*wire.MsgTx)) gobcy.TXSkel {
+	pre, _ := hex.DecodeString(skel.ToSignTX[k])
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.DeserializeNoWitness(bytes.NewReader(pre[:len(pre)-4])); err != nil {
+		t.Fatal("ToSignTX isn't a legacy preimage: ", err)
+	}
+	tamper(tx)
+	var buf bytes.Buffer
+	tx.SerializeNoWitness(&buf)
+	buf.Write(pre[len(pre)-4:])
+	return resigned(skel, k, buf.Bytes())
+}
+
+//multisigAddr returns the P2SH address of an n-of-len(pubkeys) multisig.
+func multisigAddr(t *testing.T, pubkeys []string, n int, params *chaincfg.Params) string {
+	var keys []*btcutil.AddressPubKey
+	for _, k := range pubkeys {
+		pub, _ := hex.DecodeString(k)
+		key, err := btcutil.NewAddressPubKey(pub, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	redeem, _ := txscript.MultiSigScript(keys, n)
+	addr, _ := btcutil.NewAddressScriptHash(redeem, params)
+	return addr.EncodeAddress()
+}
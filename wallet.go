@@ -1,6 +1,7 @@
 package gobcy
 
 import (
+	"context"
 	"strconv"
 	"strings"
 )
@@ -12,17 +13,29 @@ import (
 //a wallet name balance:
 //  addr, err := api.GetAddrBal("your-wallet-name", nil)
 func (api *API) CreateWallet(req Wallet) (wal Wallet, err error) {
+	return api.CreateWalletCtx(context.Background(), req)
+}
+
+//CreateWalletCtx is like CreateWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) CreateWalletCtx(ctx context.Context, req Wallet) (wal Wallet, err error) {
 	u, err := api.buildURL("/wallets", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &req, &wal)
+	err = api.postResponse(ctx, u, &req, &wal)
 	return
 }
 
 //ListWallets lists all known Wallets associated with
 //this token/coin/chain.
 func (api *API) ListWallets() (names []string, err error) {
+	return api.ListWalletsCtx(context.Background())
+}
+
+//ListWalletsCtx is like ListWallets, but uses ctx
+//for the underlying HTTP request.
+func (api *API) ListWalletsCtx(ctx context.Context) (names []string, err error) {
 	u, err := api.buildURL("/wallets", nil)
 	if err != nil {
 		return
@@ -30,7 +43,7 @@ func (api *API) ListWallets() (names []string, err error) {
 	jsonResp := new(struct {
 		List []string `json:"wallet_names"`
 	})
-	err = getResponse(u, jsonResp)
+	err = api.getResponse(ctx, u, jsonResp)
 	names = jsonResp.List
 	return
 }
@@ -39,11 +52,17 @@ func (api *API) ListWallets() (names []string, err error) {
 //API token/coin/chain, and whether it's an HD wallet or
 //not.
 func (api *API) GetWallet(name string) (wal Wallet, err error) {
+	return api.GetWalletCtx(context.Background(), name)
+}
+
+//GetWalletCtx is like GetWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetWalletCtx(ctx context.Context, name string) (wal Wallet, err error) {
 	u, err := api.buildURL("/wallets/"+name, nil)
 	if err != nil {
 		return
 	}
-	err = getResponse(u, &wal)
+	err = api.getResponse(ctx, u, &wal)
 	return
 }
 
@@ -53,25 +72,38 @@ func (api *API) GetWallet(name string) (wal Wallet, err error) {
 //  "omitAddr," if true will omit wallet addresses in your
 //  response. Useful to speed up the API call for larger wallets.
 func (api *API) AddAddrWallet(name string, addrs []string, omitAddr bool) (wal Wallet, err error) {
+	return api.AddAddrWalletCtx(context.Background(), name, addrs, omitAddr)
+}
+
+//AddAddrWalletCtx is like AddAddrWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) AddAddrWalletCtx(ctx context.Context, name string, addrs []string, omitAddr bool) (wal Wallet, err error) {
 	params := map[string]string{"omitWalletAddresses": strconv.FormatBool(omitAddr)}
 	u, err := api.buildURL("/wallets/"+name+"/addresses", params)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, &Wallet{Addresses: addrs}, &wal)
+	err = api.postResponse(ctx, u, &Wallet{Addresses: addrs}, &wal)
 	return
 }
 
 //GetAddrWallet returns a slice of addresses associated with
 //a named Wallet, associated with the API token/coin/chain.
 //Takes an optionally-nil URL parameter map.
+//See AddrQuery for its supported params.
 func (api *API) GetAddrWallet(name string, params map[string]string) (addrs []string, err error) {
+	return api.GetAddrWalletCtx(context.Background(), name, params)
+}
+
+//GetAddrWalletCtx is like GetAddrWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GetAddrWalletCtx(ctx context.Context, name string, params map[string]string) (addrs []string, err error) {
 	u, err := api.buildURL("/wallets/"+name+"/addresses", params)
 	if err != nil {
 		return
 	}
 	var wal Wallet
-	err = getResponse(u, &wal)
+	err = api.getResponse(ctx, u, &wal)
 	addrs = wal.Addresses
 	return
 }
@@ -79,12 +111,18 @@ func (api *API) GetAddrWallet(name string, params map[string]string) (addrs []st
 //DeleteAddrWallet deletes a slice of addresses associated with
 //a named Wallet, associated with the API token/coin/chain.
 func (api *API) DeleteAddrWallet(name string, addrs []string) (err error) {
+	return api.DeleteAddrWalletCtx(context.Background(), name, addrs)
+}
+
+//DeleteAddrWalletCtx is like DeleteAddrWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) DeleteAddrWalletCtx(ctx context.Context, name string, addrs []string) (err error) {
 	u, err := api.buildURL("/wallets/"+name+"/addresses",
 		map[string]string{"address": strings.Join(addrs, ";")})
 	if err != nil {
 		return
 	}
-	err = deleteResponse(u)
+	err = api.deleteResponse(ctx, u)
 	return
 }
 
@@ -92,11 +130,17 @@ func (api *API) DeleteAddrWallet(name string, addrs []string) (err error) {
 //associated with the API token/coin/chain. Also returns the
 //private/WIF/public key of address via an Address Keychain.
 func (api *API) GenAddrWallet(name string) (wal Wallet, addr AddrKeychain, err error) {
+	return api.GenAddrWalletCtx(context.Background(), name)
+}
+
+//GenAddrWalletCtx is like GenAddrWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) GenAddrWalletCtx(ctx context.Context, name string) (wal Wallet, addr AddrKeychain, err error) {
 	u, err := api.buildURL("/wallets/"+name+"/addresses/generate", nil)
 	if err != nil {
 		return
 	}
-	err = postResponse(u, nil, &struct {
+	err = api.postResponse(ctx, u, nil, &struct {
 		*Wallet
 		*AddrKeychain
 	}{&wal, &addr})
@@ -106,10 +150,16 @@ func (api *API) GenAddrWallet(name string) (wal Wallet, addr AddrKeychain, err e
 //DeleteWallet deletes a named wallet associated with the
 //API token/coin/chain.
 func (api *API) DeleteWallet(name string) (err error) {
+	return api.DeleteWalletCtx(context.Background(), name)
+}
+
+//DeleteWalletCtx is like DeleteWallet, but uses ctx
+//for the underlying HTTP request.
+func (api *API) DeleteWalletCtx(ctx context.Context, name string) (err error) {
 	u, err := api.buildURL("/wallets/"+name, nil)
 	if err != nil {
 		return
 	}
-	err = deleteResponse(u)
+	err = api.deleteResponse(ctx, u)
 	return
 }